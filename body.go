@@ -0,0 +1,141 @@
+package mux
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ErrBodyTooLarge is returned when reading a request body beyond the limit
+// set by the RequestBody middleware.
+var ErrBodyTooLarge = errors.New("mux: request body too large")
+
+type bodyLimitKey struct{}
+
+type contentTypesKey struct{}
+
+// WithBodyLimit is a route option applier overriding the body size limit set
+// by the RequestBody middleware. A zero or negative limit opts the route out.
+func WithBodyLimit(maxBytes int64) RouteOptionApplier {
+	return WithValue(bodyLimitKey{}, maxBytes)
+}
+
+// WithContentTypes is a route option applier overriding the content types
+// accepted by the RequestBody middleware, e.g. "application/json" or
+// "image/*".
+func WithContentTypes(types ...string) RouteOptionApplier {
+	return WithValue(contentTypesKey{}, types)
+}
+
+// RequestBody returns a Middleware which limits the request body to maxBytes
+// and, when contentTypes are given, rejects bodies of other content types
+// with 415 Unsupported Media Type before the handler runs. Routes registered
+// with WithBodyLimit or WithContentTypes use their own configuration.
+//
+// Requests declaring a larger Content-Length are rejected with 413 Request
+// Entity Too Large before the handler runs. Otherwise, reading beyond the
+// limit fails with ErrBodyTooLarge and the response of the handler is
+// replaced by the same 413 response, unless it has already been written.
+func RequestBody(maxBytes int64, contentTypes ...string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, types := maxBytes, contentTypes
+			if rt := GetRoute(r.Context()); rt != nil {
+				if v, ok := rt.Value(bodyLimitKey{}).(int64); ok {
+					limit = v
+				}
+				if v, ok := rt.Value(contentTypesKey{}).([]string); ok {
+					types = v
+				}
+			}
+
+			if len(types) > 0 && hasBody(r) && !acceptsContentType(types, r.Header.Get("Content-Type")) {
+				Error(w, r, http.StatusUnsupportedMediaType)
+				return
+			}
+
+			if limit <= 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > limit {
+				bodyTooLarge(w, r)
+				return
+			}
+
+			body := &limitedBody{rc: http.MaxBytesReader(w, r.Body, limit), limit: limit}
+			// the response is replaced once the body has exceeded the limit.
+			rw := newResponseWriter(w, func(int) int {
+				if !body.exceeded {
+					return 0
+				}
+				bodyTooLarge(w, r)
+				return http.StatusRequestEntityTooLarge
+			})
+
+			r.Body = body
+			next.ServeHTTP(rw, r)
+
+			if !rw.Written() && body.exceeded {
+				bodyTooLarge(w, r)
+			}
+		})
+	}
+}
+
+func bodyTooLarge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Connection", "close")
+	Error(w, r, http.StatusRequestEntityTooLarge)
+}
+
+func hasBody(r *http.Request) bool {
+	return r.ContentLength > 0 || len(r.TransferEncoding) > 0
+}
+
+func acceptsContentType(types []string, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range types {
+		t = strings.ToLower(t)
+		switch {
+		case t == "*/*", t == mediaType:
+			return true
+		case strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1]):
+			return true
+		}
+	}
+
+	return false
+}
+
+// limitedBody reports ErrBodyTooLarge when the body exceeds the limit.
+type limitedBody struct {
+	rc       io.ReadCloser
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	b.read += int64(n)
+
+	// http.MaxBytesReader only fails with a non EOF error after reading
+	// exactly limit bytes when the body is too large.
+	if err != nil && err != io.EOF && b.read >= b.limit {
+		b.exceeded = true
+		err = ErrBodyTooLarge
+	}
+
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.rc.Close()
+}
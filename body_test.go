@@ -0,0 +1,79 @@
+package mux_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/josestg/mux"
+)
+
+func TestRequestBody(t *testing.T) {
+	handler := mux.New()
+	handler.Use(mux.RequestBody(16, "application/json"))
+
+	var readErr error
+	decode := func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]any
+		if readErr = json.NewDecoder(r.Body).Decode(&v); readErr != nil {
+			http.Error(w, readErr.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	handler.HandleFunc(http.MethodPost, "/books", decode)
+	handler.HandleFunc(http.MethodPost, "/uploads", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, strings.Repeat("x", int(n)))
+	}, mux.WithBodyLimit(1024), mux.WithContentTypes("image/*"))
+
+	tests := []struct {
+		path          string
+		contentType   string
+		body          string
+		chunked       bool
+		expStatusCode int
+	}{
+		{path: "/books", contentType: "application/json; charset=utf-8", body: `{"a":"b"}`, expStatusCode: http.StatusCreated},
+		{path: "/books", contentType: "text/plain", body: `{"a":"b"}`, expStatusCode: http.StatusUnsupportedMediaType},
+		{path: "/books", contentType: "", body: `{"a":"b"}`, expStatusCode: http.StatusUnsupportedMediaType},
+		{path: "/books", contentType: "application/json", body: `{"title":"a very long title"}`, expStatusCode: http.StatusRequestEntityTooLarge},
+		{path: "/books", contentType: "application/json", body: `{"title":"a very long title"}`, chunked: true, expStatusCode: http.StatusRequestEntityTooLarge},
+		{path: "/uploads", contentType: "image/png", body: strings.Repeat("x", 512), expStatusCode: http.StatusCreated},
+		{path: "/uploads", contentType: "application/json", body: `{}`, expStatusCode: http.StatusUnsupportedMediaType},
+	}
+
+	for i, tc := range tests {
+		readErr = nil
+		r := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			r.Header.Set("Content-Type", tc.contentType)
+		}
+		if tc.chunked {
+			r.ContentLength = -1
+			r.TransferEncoding = []string{"chunked"}
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("case %d: expected %d; got %d", i, tc.expStatusCode, rec.Code)
+		}
+
+		if tc.expStatusCode == http.StatusRequestEntityTooLarge {
+			if got := strings.TrimSpace(rec.Body.String()); got != http.StatusText(http.StatusRequestEntityTooLarge) {
+				t.Fatalf("case %d: expected a consistent body; got %q", i, got)
+			}
+		}
+
+		if tc.chunked && readErr != mux.ErrBodyTooLarge {
+			t.Fatalf("case %d: expected %v; got %v", i, mux.ErrBodyTooLarge, readErr)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+// Package cache provides a Mux middleware which caches the responses of GET
+// routes in process, as a shared cache honoring their Cache-Control header.
+//
+// Responses are keyed by the route and its version, its URL variables, the
+// query and the selected request headers the responses vary on. A response is stored
+// when it has a max-age or s-maxage, and with stale-while-revalidate it is
+// served stale while being refreshed in the background:
+//
+//	c := cache.New()
+//	m.Use(c.Middleware)
+//	m.HandleFunc(http.MethodGet, "/books/:id", getBook, mux.WithName("books.get"))
+//
+// Entries are invalidated explicitly, e.g. after the resource changed:
+//
+//	_ = c.Invalidate(ctx, "books.get", map[string]string{"id": id})
+//
+// See: https://www.rfc-editor.org/rfc/rfc9111.
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/clock"
+	"github.com/josestg/mux/websocket"
+)
+
+// HeaderStatus is the response header telling whether the response was
+// served from the cache: HIT, STALE or MISS.
+const HeaderStatus = "X-Cache"
+
+// OptionApplier is a function for applying option.
+type OptionApplier func(o *Options)
+
+// Options holds the cache optional fields.
+type Options struct {
+	// Store keeps the cached responses. When nil, a MemoryStore limited
+	// to MaxBytes is used.
+	Store Store
+
+	// MaxBytes is the memory limit of the default MemoryStore.
+	MaxBytes int64
+
+	// Vary are the request headers which are part of the key. Responses
+	// varying on other headers are not cached.
+	Vary []string
+
+	// Clock tells the current time.
+	Clock clock.Clock
+}
+
+// Default is a default option applier.
+func Default() OptionApplier {
+	return func(o *Options) {
+		o.Store = nil
+		o.MaxBytes = 64 << 20
+		o.Vary = []string{"Accept", "Accept-Encoding"}
+		o.Clock = clock.System{}
+	}
+}
+
+// WithStore sets the Store.
+func WithStore(s Store) OptionApplier {
+	return func(o *Options) {
+		o.Store = s
+	}
+}
+
+// WithMaxBytes sets the MaxBytes.
+func WithMaxBytes(n int64) OptionApplier {
+	return func(o *Options) {
+		o.MaxBytes = n
+	}
+}
+
+// WithVary adds request headers to the Vary.
+func WithVary(headers ...string) OptionApplier {
+	return func(o *Options) {
+		o.Vary = append(o.Vary, headers...)
+	}
+}
+
+// WithClock sets the Clock.
+func WithClock(c clock.Clock) OptionApplier {
+	return func(o *Options) {
+		o.Clock = c
+	}
+}
+
+// Cache caches the responses of the routes it is used on.
+type Cache struct {
+	options Options
+
+	mu           sync.Mutex
+	revalidating map[string]bool
+}
+
+// New creates a new Cache with Default option.
+func New(appliers ...OptionApplier) *Cache {
+	var options Options
+	Default()(&options)
+
+	for _, apply := range appliers {
+		apply(&options)
+	}
+
+	if options.Store == nil {
+		options.Store = NewMemoryStore(options.MaxBytes, options.Clock)
+	}
+
+	for i, h := range options.Vary {
+		options.Vary[i] = textproto.CanonicalMIMEHeaderKey(h)
+	}
+
+	return &Cache{
+		options:      options,
+		revalidating: make(map[string]bool),
+	}
+}
+
+// Middleware caches the responses of the matched GET routes. Requests with
+// Cache-Control: no-cache skip the lookup and refresh the entry, and
+// requests with no-store bypass the cache, as well as the WebSocket upgrade
+// requests. When the Store fails, the request is served by the handler.
+//
+// Only the headers set by the handler are stored, and a cached response
+// keeps the headers already set for the request, e.g. the rate limit
+// headers of a previous middleware. The response body is only buffered when
+// its status code and header allow it to be stored.
+//
+// Since a variant is selected after the lookup, the responses of the routes
+// registered with HandleVariants are not stored. A route gated by WithFlag
+// shares the entries of the routes with the same pattern, unless they have
+// their own name given by WithName.
+func (c *Cache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rt := mux.GetRoute(r.Context())
+		if rt == nil || r.Method != http.MethodGet || websocket.IsUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		d := parseCacheControl(r.Header)
+		if d.has("no-store") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := c.key(rt, r)
+		if !d.has("no-cache") {
+			e, ok, err := c.options.Store.Get(r.Context(), key)
+			if err == nil && ok {
+				now := c.options.Clock.Now()
+				switch {
+				case now.Before(e.Expires):
+					c.write(w, e, now, "HIT")
+					return
+				case now.Before(e.StaleUntil):
+					c.write(w, e, now, "STALE")
+					c.revalidate(r, key, next)
+					return
+				}
+			}
+		}
+
+		w.Header().Set(HeaderStatus, "MISS")
+
+		// the headers set before the handler runs, e.g. by the previous
+		// middlewares, belong to this request only.
+		rec := &recorder{c: c, r: r, w: w, base: w.Header().Clone()}
+		next.ServeHTTP(rec.writer(), r)
+		c.store(key, rec)
+	})
+}
+
+// Invalidate deletes the cached responses of the route with the given URL
+// variables, in every version. The route is the name of the route, or its
+// pattern without the version prefix when it has no name. A nil vars
+// deletes every response of the route.
+func (c *Cache) Invalidate(ctx context.Context, route string, vars map[string]string) error {
+	return c.options.Store.DeletePrefix(ctx, routeKey(route, vars))
+}
+
+// routeKey returns the prefix of the keys of the route and vars.
+func routeKey(route string, vars map[string]string) string {
+	if vars == nil {
+		return route + "\x00"
+	}
+
+	values := make(url.Values, len(vars))
+	for k, v := range vars {
+		values.Set(k, v)
+	}
+	return route + "\x00" + values.Encode() + "\x00"
+}
+
+func (c *Cache) key(rt *mux.Route, r *http.Request) string {
+	route := rt.Name
+	if route == "" {
+		route = rt.Pattern
+	}
+
+	var b strings.Builder
+	b.WriteString(routeKey(route, mux.GetVars(r.Context())))
+	b.WriteString(r.URL.Query().Encode())
+	for _, h := range c.options.Vary {
+		b.WriteByte(0)
+		b.WriteString(strings.Join(r.Header.Values(h), ","))
+	}
+
+	// the version comes last, so that Invalidate deletes the responses of
+	// every version.
+	b.WriteByte(0)
+	b.WriteString(rt.Version)
+	return b.String()
+}
+
+// write answers with the entry. The headers already set for the request
+// are kept.
+func (c *Cache) write(w http.ResponseWriter, e *Entry, now time.Time, status string) {
+	h := w.Header()
+	for k, v := range e.Header {
+		if _, ok := h[k]; !ok {
+			h[k] = append([]string(nil), v...)
+		}
+	}
+
+	h.Set("Age", strconv.Itoa(int(now.Sub(e.Stored).Seconds())))
+	h.Set(HeaderStatus, status)
+
+	w.WriteHeader(e.Status)
+	_, _ = w.Write(e.Body)
+}
+
+// revalidate refreshes the entry in the background, once at a time per key.
+func (c *Cache) revalidate(r *http.Request, key string, next http.Handler) {
+	c.mu.Lock()
+	if c.revalidating[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.revalidating[key] = true
+	c.mu.Unlock()
+
+	r = r.Clone(detachedContext{r.Context()})
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.revalidating, key)
+			c.mu.Unlock()
+
+			// a panicking handler must not crash the process, there is
+			// no server to recover it here.
+			_ = recover()
+		}()
+
+		rec := &recorder{c: c, r: r, w: discardWriter{h: make(http.Header)}}
+		next.ServeHTTP(rec.writer(), r)
+		c.store(key, rec)
+	}()
+}
+
+// cacheableStatus are the status codes stored when the response allows it.
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusGone:                 true,
+}
+
+// cacheable reports whether the response with the status code and header
+// can be stored, and for how long it is fresh then stale.
+func (c *Cache) cacheable(r *http.Request, status int, h http.Header) (fresh, stale time.Duration, ok bool) {
+	if !cacheableStatus[status] || h.Get("Set-Cookie") != "" || !c.varies(h) {
+		return 0, 0, false
+	}
+
+	if mux.GetVariant(r.Context()) != "" {
+		return 0, 0, false
+	}
+	return freshness(r, h)
+}
+
+func (c *Cache) store(key string, rec *recorder) {
+	if !rec.recording {
+		return
+	}
+
+	now := c.options.Clock.Now()
+	e := &Entry{
+		Status:     rec.status,
+		Header:     rec.handlerHeader(),
+		Body:       rec.body,
+		Stored:     now,
+		Expires:    now.Add(rec.fresh),
+		StaleUntil: now.Add(rec.fresh + rec.stale),
+	}
+
+	_ = c.options.Store.Set(context.Background(), key, e, rec.fresh+rec.stale)
+}
+
+// varies reports whether the response only varies on the headers of the
+// key.
+func (c *Cache) varies(h http.Header) bool {
+	for _, line := range h.Values("Vary") {
+		for _, name := range strings.Split(line, ",") {
+			name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+
+			if name == "*" || !contains(c.options.Vary, name) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func contains(s []string, v string) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// recorder records the response written to w, once its header tells it can
+// be stored.
+type recorder struct {
+	c    *Cache
+	r    *http.Request
+	w    http.ResponseWriter
+	base http.Header
+
+	header    http.Header
+	status    int
+	body      []byte
+	recording bool
+	fresh     time.Duration
+	stale     time.Duration
+}
+
+// writer returns the writer given to the handler.
+func (rec *recorder) writer() http.ResponseWriter {
+	return mux.HookResponseWriter(rec.w, mux.ResponseWriterHooks{
+		WriteHeader: rec.writeHeader,
+		Write:       rec.write,
+		Flush:       rec.flush,
+	})
+}
+
+func (rec *recorder) writeHeader(code int) {
+	if rec.status == 0 && code >= http.StatusOK {
+		rec.status = code
+		rec.header = rec.w.Header().Clone()
+		rec.fresh, rec.stale, rec.recording = rec.c.cacheable(rec.r, code, rec.header)
+	}
+	rec.w.WriteHeader(code)
+}
+
+func (rec *recorder) write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.writeHeader(http.StatusOK)
+	}
+
+	if rec.recording {
+		if int64(len(rec.body)+len(b)) > rec.c.options.MaxBytes {
+			rec.recording, rec.body = false, nil
+		} else {
+			rec.body = append(rec.body, b...)
+		}
+	}
+	return rec.w.Write(b)
+}
+
+func (rec *recorder) flush() {
+	if rec.status == 0 {
+		rec.writeHeader(http.StatusOK)
+	}
+	rec.w.(http.Flusher).Flush()
+}
+
+// handlerHeader returns the recorded header without the headers of the base
+// left unchanged by the handler.
+func (rec *recorder) handlerHeader() http.Header {
+	h := make(http.Header, len(rec.header))
+	for k, v := range rec.header {
+		if k == HeaderStatus || equalValues(rec.base[k], v) {
+			continue
+		}
+		h[k] = v
+	}
+	return h
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// discardWriter is the writer of the background revalidations, whose
+// response is only recorded.
+type discardWriter struct {
+	h http.Header
+}
+
+func (d discardWriter) Header() http.Header { return d.h }
+
+func (discardWriter) WriteHeader(int) {}
+
+func (discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+// detachedContext keeps the values of its parent without its cancellation,
+// since the revalidation outlives the request.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+func (d detachedContext) Value(key any) any { return d.parent.Value(key) }
@@ -0,0 +1,464 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/cache"
+	"github.com/josestg/mux/clock"
+)
+
+type counter struct {
+	mu sync.Mutex
+	n  map[string]int
+}
+
+func (c *counter) inc(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n[key]++
+	return c.n[key]
+}
+
+func TestCache(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	c := cache.New(cache.WithClock(clk))
+	calls := &counter{n: make(map[string]int)}
+
+	m := mux.New()
+	m.Use(c.Middleware)
+
+	handler := func(cacheControl string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			n := calls.inc(r.URL.Path)
+			if cacheControl != "" {
+				w.Header().Set("Cache-Control", cacheControl)
+			}
+			w.Header().Set("Vary", "Accept")
+			_, _ = fmt.Fprintf(w, "%s %s #%d", r.URL.Path, r.Header.Get("Accept"), n)
+		}
+	}
+
+	m.HandleFunc(http.MethodGet, "/books/:id", handler("max-age=60"), mux.WithName("books.get"))
+	m.HandleFunc(http.MethodGet, "/authors", handler("public, s-maxage=10, max-age=0"))
+	m.HandleFunc(http.MethodGet, "/private", handler("private, max-age=60"))
+	m.HandleFunc(http.MethodGet, "/none", handler(""))
+	m.HandleFunc(http.MethodGet, "/cookie", func(w http.ResponseWriter, r *http.Request) {
+		calls.inc(r.URL.Path)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Set-Cookie", "a=b")
+	})
+	m.HandleFunc(http.MethodGet, "/user-agent", func(w http.ResponseWriter, r *http.Request) {
+		calls.inc(r.URL.Path)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "User-Agent")
+	})
+
+	do := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		return rec
+	}
+
+	tests := []struct {
+		advance   time.Duration
+		path      string
+		header    map[string]string
+		expStatus string
+		expBody   string
+		expAge    string
+	}{
+		{path: "/books/1", expStatus: "MISS", expBody: "/books/1  #1"},
+		{advance: 5 * time.Second, path: "/books/1", expStatus: "HIT", expBody: "/books/1  #1", expAge: "5"},
+		{path: "/books/2", expStatus: "MISS", expBody: "/books/2  #1"},
+		{path: "/books/1?page=2", expStatus: "MISS", expBody: "/books/1  #2"},
+		{path: "/books/1", header: map[string]string{"Accept": "text/plain"}, expStatus: "MISS", expBody: "/books/1 text/plain #3"},
+		{path: "/books/1", header: map[string]string{"Accept": "text/plain"}, expStatus: "HIT", expBody: "/books/1 text/plain #3"},
+		{path: "/books/1", header: map[string]string{"Cache-Control": "no-cache"}, expStatus: "MISS", expBody: "/books/1  #4"},
+		{path: "/books/1", expStatus: "HIT", expBody: "/books/1  #4", expAge: "0"},
+		{advance: 61 * time.Second, path: "/books/1", expStatus: "MISS", expBody: "/books/1  #5"},
+		{path: "/authors", header: map[string]string{"Authorization": "Bearer x"}, expStatus: "MISS", expBody: "/authors  #1"},
+		{path: "/authors", expStatus: "HIT", expBody: "/authors  #1"},
+		{path: "/private", expStatus: "MISS", expBody: "/private  #1"},
+		{path: "/private", expStatus: "MISS", expBody: "/private  #2"},
+		{path: "/none", expStatus: "MISS", expBody: "/none  #1"},
+		{path: "/none", expStatus: "MISS", expBody: "/none  #2"},
+		{path: "/cookie", expStatus: "MISS"},
+		{path: "/cookie", expStatus: "MISS"},
+		{path: "/user-agent", expStatus: "MISS"},
+		{path: "/user-agent", expStatus: "MISS"},
+	}
+
+	for i, tc := range tests {
+		clk.Advance(tc.advance)
+		rec := do(tc.path, tc.header)
+
+		if got := rec.Header().Get(cache.HeaderStatus); got != tc.expStatus {
+			t.Fatalf("%d: expected %s %q; got %q", i, cache.HeaderStatus, tc.expStatus, got)
+		}
+
+		if got := rec.Body.String(); got != tc.expBody {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.expBody, got)
+		}
+
+		if tc.expAge != "" && rec.Header().Get("Age") != tc.expAge {
+			t.Fatalf("%d: expected age %q; got %q", i, tc.expAge, rec.Header().Get("Age"))
+		}
+	}
+}
+
+func TestCache_RequestHeaders(t *testing.T) {
+	c := cache.New(cache.WithClock(clock.NewFake(time.Unix(0, 0))))
+	calls := &counter{n: make(map[string]int)}
+
+	m := mux.New()
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(10-calls.inc("requests")))
+			w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+			next.ServeHTTP(w, r)
+		})
+	})
+	m.Use(c.Middleware)
+
+	m.HandleFunc(http.MethodGet, "/books", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("X-Request-Id", "handler")
+		_, _ = w.Write([]byte("books"))
+	})
+
+	tests := []struct {
+		requestID    string
+		expStatus    string
+		expRemaining string
+		expID        string
+	}{
+		{requestID: "a", expStatus: "MISS", expRemaining: "9", expID: "handler"},
+		{requestID: "b", expStatus: "HIT", expRemaining: "8", expID: "b"},
+		{requestID: "c", expStatus: "HIT", expRemaining: "7", expID: "c"},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/books", nil)
+		r.Header.Set("X-Request-Id", tc.requestID)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		if got := rec.Header().Get(cache.HeaderStatus); got != tc.expStatus {
+			t.Fatalf("%d: expected %s %q; got %q", i, cache.HeaderStatus, tc.expStatus, got)
+		}
+
+		if got := rec.Header().Get("RateLimit-Remaining"); got != tc.expRemaining {
+			t.Fatalf("%d: expected RateLimit-Remaining %q; got %q", i, tc.expRemaining, got)
+		}
+
+		if got := rec.Header().Get("X-Request-Id"); got != tc.expID {
+			t.Fatalf("%d: expected X-Request-Id %q; got %q", i, tc.expID, got)
+		}
+
+		if got := rec.Header().Get("Content-Type"); got != "text/plain" {
+			t.Fatalf("%d: expected Content-Type %q; got %q", i, "text/plain", got)
+		}
+	}
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	c := cache.New()
+	calls := &counter{n: make(map[string]int)}
+
+	m := mux.New()
+	m.Use(c.Middleware)
+	m.HandleFunc(http.MethodGet, "/books/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = fmt.Fprintf(w, "#%d", calls.inc(r.URL.Path))
+	}, mux.WithName("books.get"))
+	m.HandleFunc(http.MethodGet, "/authors", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = fmt.Fprintf(w, "#%d", calls.inc(r.URL.Path))
+	})
+
+	get := func(path string) string {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Body.String()
+	}
+
+	for _, path := range []string{"/books/1", "/books/2", "/books/1?page=2", "/authors"} {
+		get(path)
+	}
+
+	ctx := context.Background()
+	if err := c.Invalidate(ctx, "books.get", map[string]string{"id": "1"}); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	tests := []struct {
+		path string
+		exp  string
+	}{
+		{path: "/books/1", exp: "#3"},
+		{path: "/books/1?page=2", exp: "#4"},
+		{path: "/books/2", exp: "#1"},
+		{path: "/authors", exp: "#1"},
+	}
+
+	for i, tc := range tests {
+		if got := get(tc.path); got != tc.exp {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.exp, got)
+		}
+	}
+
+	_ = c.Invalidate(ctx, "books.get", nil)
+	_ = c.Invalidate(ctx, "/authors", map[string]string{})
+
+	if got := get("/books/2"); got != "#2" {
+		t.Fatalf("expected body %q; got %q", "#2", got)
+	}
+
+	if got := get("/authors"); got != "#2" {
+		t.Fatalf("expected body %q; got %q", "#2", got)
+	}
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	c := cache.New(cache.WithClock(clk))
+	calls := &counter{n: make(map[string]int)}
+	revalidated := make(chan struct{}, 1)
+
+	m := mux.New()
+	m.Use(c.Middleware)
+	m.HandleFunc(http.MethodGet, "/books", func(w http.ResponseWriter, r *http.Request) {
+		n := calls.inc(r.URL.Path)
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=30")
+		_, _ = fmt.Fprintf(w, "#%d", n)
+		if n > 1 {
+			revalidated <- struct{}{}
+		}
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books", nil))
+		return rec
+	}
+
+	get()
+	clk.Advance(15 * time.Second)
+
+	rec := get()
+	if rec.Header().Get(cache.HeaderStatus) != "STALE" || rec.Body.String() != "#1" {
+		t.Fatalf("expected stale #1; got %s %q", rec.Header().Get(cache.HeaderStatus), rec.Body.String())
+	}
+
+	select {
+	case <-revalidated:
+	case <-time.After(time.Second):
+		t.Fatalf("expected background revalidation")
+	}
+
+	// the refreshed entry is stored right after the handler returns.
+	deadline := time.Now().Add(time.Second)
+	for {
+		rec = get()
+		if rec.Body.String() == "#2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected refreshed #2; got %q", rec.Body.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := rec.Header().Get(cache.HeaderStatus); got != "HIT" {
+		t.Fatalf("expected HIT; got %q", got)
+	}
+
+	clk.Advance(41 * time.Second)
+	if rec = get(); rec.Header().Get(cache.HeaderStatus) != "MISS" {
+		t.Fatalf("expected MISS; got %q", rec.Header().Get(cache.HeaderStatus))
+	}
+}
+
+func TestMemoryStore_LRU(t *testing.T) {
+	ctx := context.Background()
+	ms := cache.NewMemoryStore(100, nil)
+
+	entry := func(n int) *cache.Entry {
+		return &cache.Entry{Status: http.StatusOK, Body: make([]byte, n)}
+	}
+
+	_ = ms.Set(ctx, "a", entry(40), time.Minute)
+	_ = ms.Set(ctx, "b", entry(40), time.Minute)
+
+	// a is the most recently used, so b is evicted.
+	_, _, _ = ms.Get(ctx, "a")
+	_ = ms.Set(ctx, "c", entry(40), time.Minute)
+
+	if _, ok, _ := ms.Get(ctx, "b"); ok {
+		t.Fatalf("expected b evicted")
+	}
+
+	for _, key := range []string{"a", "c"} {
+		if _, ok, _ := ms.Get(ctx, key); !ok {
+			t.Fatalf("expected %s stored", key)
+		}
+	}
+
+	if ms.Size() > 100 {
+		t.Fatalf("expected size at most 100; got %d", ms.Size())
+	}
+
+	_ = ms.Set(ctx, "huge", entry(200), time.Minute)
+	if _, ok, _ := ms.Get(ctx, "huge"); ok || ms.Len() != 2 {
+		t.Fatalf("expected entries larger than the limit not stored")
+	}
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Unix(0, 0))
+	ms := cache.NewMemoryStore(1<<10, clk)
+
+	_ = ms.Set(ctx, "a", &cache.Entry{}, time.Second)
+	clk.Advance(time.Second)
+
+	if _, ok, _ := ms.Get(ctx, "a"); ok || ms.Len() != 0 {
+		t.Fatalf("expected a expired")
+	}
+}
+
+func TestCache_Variants(t *testing.T) {
+	c := cache.New(cache.WithClock(clock.NewFake(time.Unix(0, 0))))
+
+	variant := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte(name))
+		})
+	}
+
+	m := mux.New()
+	m.Use(c.Middleware)
+	m.HandleVariants(http.MethodGet, "/checkout", []mux.Variant{
+		{Name: "stable", Weight: 1, Handler: variant("stable")},
+		{Name: "canary", Weight: 1, Handler: variant("canary")},
+	}, mux.WithStickyHeader("X-User"))
+
+	for i, user := range []string{"a", "b", "c", "d", "a", "b", "c", "d"} {
+		r := httptest.NewRequest(http.MethodGet, "/checkout", nil)
+		r.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		if got := rec.Header().Get(cache.HeaderStatus); got != "MISS" {
+			t.Fatalf("%d: expected %s %q; got %q", i, cache.HeaderStatus, "MISS", got)
+		}
+	}
+}
+
+func TestCache_Flags(t *testing.T) {
+	c := cache.New(cache.WithClock(clock.NewFake(time.Unix(0, 0))))
+
+	var on bool
+	flags := func(ctx context.Context, flag string) bool { return on }
+
+	books := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte(body))
+		}
+	}
+
+	m := mux.New()
+	m.Use(c.Middleware)
+	m.HandleFunc(http.MethodGet, "/books", books("books v2"), mux.WithFlag(flags, "books-v2"), mux.WithName("books.list.v2"))
+	m.HandleFunc(http.MethodGet, "/books", books("books"), mux.WithName("books.list"))
+
+	tests := []struct {
+		on        bool
+		expBody   string
+		expStatus string
+	}{
+		{on: false, expBody: "books", expStatus: "MISS"},
+		{on: true, expBody: "books v2", expStatus: "MISS"},
+		{on: false, expBody: "books", expStatus: "HIT"},
+		{on: true, expBody: "books v2", expStatus: "HIT"},
+	}
+
+	for i, tc := range tests {
+		on = tc.on
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books", nil))
+
+		if rec.Body.String() != tc.expBody {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.expBody, rec.Body.String())
+		}
+
+		if got := rec.Header().Get(cache.HeaderStatus); got != tc.expStatus {
+			t.Fatalf("%d: expected %s %q; got %q", i, cache.HeaderStatus, tc.expStatus, got)
+		}
+	}
+}
+
+func TestCache_Versions(t *testing.T) {
+	c := cache.New(cache.WithClock(clock.NewFake(time.Unix(0, 0))))
+
+	user := func(version string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte(version + " " + mux.GetVars(r.Context()).Get("id")))
+		}
+	}
+
+	m := mux.New()
+	m.Use(c.Middleware)
+	m.Version("v1").HandleFunc(http.MethodGet, "/users/:id", user("v1"), mux.WithName("users.get"))
+	m.Version("v2").HandleFunc(http.MethodGet, "/users/:id", user("v2"), mux.WithName("users.get"))
+
+	tests := []struct {
+		path       string
+		invalidate bool
+		expBody    string
+		expStatus  string
+	}{
+		{path: "/v1/users/1", expBody: "v1 1", expStatus: "MISS"},
+		{path: "/v2/users/1", expBody: "v2 1", expStatus: "MISS"},
+		{path: "/v1/users/1", expBody: "v1 1", expStatus: "HIT"},
+		{path: "/v2/users/1", expBody: "v2 1", expStatus: "HIT"},
+		// the responses of every version are invalidated.
+		{path: "/v1/users/1", invalidate: true, expBody: "v1 1", expStatus: "MISS"},
+		{path: "/v2/users/1", expBody: "v2 1", expStatus: "MISS"},
+	}
+
+	for i, tc := range tests {
+		if tc.invalidate {
+			if err := c.Invalidate(context.Background(), "users.get", map[string]string{"id": "1"}); err != nil {
+				t.Fatalf("%d: expected no error; got %v", i, err)
+			}
+		}
+
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if rec.Body.String() != tc.expBody {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.expBody, rec.Body.String())
+		}
+
+		if got := rec.Header().Get(cache.HeaderStatus); got != tc.expStatus {
+			t.Fatalf("%d: expected %s %q; got %q", i, cache.HeaderStatus, tc.expStatus, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// directives are the parsed directives of a Cache-Control header, with
+// lower-case names and unquoted values.
+type directives map[string]string
+
+func parseCacheControl(h http.Header) directives {
+	d := make(directives)
+	for _, line := range h.Values("Cache-Control") {
+		for _, part := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name == "" {
+				continue
+			}
+			d[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return d
+}
+
+func (d directives) has(name string) bool {
+	_, ok := d[name]
+	return ok
+}
+
+// seconds returns the delta-seconds value of the directive.
+func (d directives) seconds(name string) (time.Duration, bool) {
+	v, ok := d[name]
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// freshness returns how long a response is fresh for a shared cache and how
+// long it may be served stale while revalidating. It reports false when the
+// response must not be stored.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9111 and
+// https://www.rfc-editor.org/rfc/rfc5861.
+func freshness(req *http.Request, res http.Header) (fresh, stale time.Duration, ok bool) {
+	d := parseCacheControl(res)
+	if d.has("no-store") || d.has("no-cache") || d.has("private") {
+		return 0, 0, false
+	}
+
+	if req.Header.Get("Authorization") != "" && !d.has("public") && !d.has("s-maxage") {
+		return 0, 0, false
+	}
+
+	fresh, ok = d.seconds("s-maxage")
+	if !ok {
+		fresh, ok = d.seconds("max-age")
+	}
+
+	if !ok || fresh == 0 {
+		return 0, 0, false
+	}
+
+	if !d.has("must-revalidate") && !d.has("proxy-revalidate") {
+		stale, _ = d.seconds("stale-while-revalidate")
+	}
+
+	return fresh, stale, true
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	c := New()
+
+	tests := []struct {
+		status       int
+		cacheControl string
+		cookie       bool
+		expRecording bool
+	}{
+		{status: http.StatusOK, cacheControl: "max-age=60", expRecording: true},
+		{status: http.StatusOK, cacheControl: "no-store"},
+		{status: http.StatusOK},
+		{status: http.StatusOK, cacheControl: "max-age=60", cookie: true},
+		{status: http.StatusInternalServerError, cacheControl: "max-age=60"},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := &recorder{c: c, r: r, w: httptest.NewRecorder()}
+
+		w := rec.writer()
+		if tc.cacheControl != "" {
+			w.Header().Set("Cache-Control", tc.cacheControl)
+		}
+		if tc.cookie {
+			w.Header().Set("Set-Cookie", "session=1")
+		}
+
+		w.WriteHeader(tc.status)
+		_, _ = io.WriteString(w, "body")
+
+		if rec.recording != tc.expRecording {
+			t.Fatalf("%d: expected recording %v; got %v", i, tc.expRecording, rec.recording)
+		}
+
+		if (rec.body != nil) != tc.expRecording {
+			t.Fatalf("%d: expected body buffered %v; got %q", i, tc.expRecording, rec.body)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/josestg/mux/clock"
+)
+
+// Entry is a cached response.
+type Entry struct {
+	// Status is the status code of the response.
+	Status int
+
+	// Header is the header of the response.
+	Header http.Header
+
+	// Body is the body of the response.
+	Body []byte
+
+	// Stored is the time the response was stored.
+	Stored time.Time
+
+	// Expires is the time the response becomes stale.
+	Expires time.Time
+
+	// StaleUntil is the time until which the stale response may still be
+	// served while it is revalidated in the background.
+	StaleUntil time.Time
+}
+
+// size estimates the memory used by the entry.
+func (e *Entry) size() int64 {
+	n := int64(len(e.Body))
+	for k, vs := range e.Header {
+		for _, v := range vs {
+			n += int64(len(k) + len(v))
+		}
+	}
+	return n
+}
+
+// Store persists the cached responses.
+type Store interface {
+	// Get returns the entry of the key. It reports false when there is
+	// none.
+	Get(ctx context.Context, key string) (*Entry, bool, error)
+
+	// Set stores the entry of the key. The entry may be evicted after ttl.
+	Set(ctx context.Context, key string, e *Entry, ttl time.Duration) error
+
+	// DeletePrefix deletes the entries of the keys starting with prefix.
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// MemoryStore is an in-memory Store which evicts the least recently used
+// entries above its memory limit.
+type MemoryStore struct {
+	clock    clock.Clock
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	entry   *Entry
+	size    int64
+	expires time.Time
+}
+
+// NewMemoryStore creates a new MemoryStore holding up to maxBytes of
+// responses. The clock decides when entries expire, a nil clock uses the
+// clock.System.
+func NewMemoryStore(maxBytes int64, c clock.Clock) *MemoryStore {
+	if c == nil {
+		c = clock.System{}
+	}
+
+	return &MemoryStore{
+		clock:    c,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get implements the Store interface.
+func (ms *MemoryStore) Get(_ context.Context, key string) (*Entry, bool, error) {
+	now := ms.clock.Now()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	el, ok := ms.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	me := el.Value.(*memoryEntry)
+	if !now.Before(me.expires) {
+		ms.remove(el)
+		return nil, false, nil
+	}
+
+	ms.lru.MoveToFront(el)
+	return me.entry, true, nil
+}
+
+// Set implements the Store interface. Entries larger than the memory limit
+// are not stored.
+func (ms *MemoryStore) Set(_ context.Context, key string, e *Entry, ttl time.Duration) error {
+	me := &memoryEntry{key: key, entry: e, size: e.size() + int64(len(key)), expires: ms.clock.Now().Add(ttl)}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if el, ok := ms.entries[key]; ok {
+		ms.remove(el)
+	}
+
+	if me.size > ms.maxBytes {
+		return nil
+	}
+
+	ms.entries[key] = ms.lru.PushFront(me)
+	ms.size += me.size
+
+	for ms.size > ms.maxBytes {
+		ms.remove(ms.lru.Back())
+	}
+	return nil
+}
+
+// DeletePrefix implements the Store interface.
+func (ms *MemoryStore) DeletePrefix(_ context.Context, prefix string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for key, el := range ms.entries {
+		if strings.HasPrefix(key, prefix) {
+			ms.remove(el)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of stored entries, including the expired entries
+// that have not been requested since.
+func (ms *MemoryStore) Len() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return len(ms.entries)
+}
+
+// Size returns the estimated memory used by the stored entries.
+func (ms *MemoryStore) Size() int64 {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.size
+}
+
+func (ms *MemoryStore) remove(el *list.Element) {
+	me := ms.lru.Remove(el).(*memoryEntry)
+	delete(ms.entries, me.key)
+	ms.size -= me.size
+}
@@ -0,0 +1,47 @@
+// Package clock provides the Clock telling the time to the middlewares
+// depending on it, e.g. to expire the rate limits or the cached responses.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// System is a Clock backed by time.Now.
+type System struct{}
+
+// Now implements the Clock interface.
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// Fake is a Clock which only moves when told to. It is meant for
+// deterministic tests.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake creates a new Fake starting at the given time.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now implements the Clock interface.
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Fake) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
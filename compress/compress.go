@@ -0,0 +1,332 @@
+// Package compress provides a Mux middleware which compresses responses
+// with the content coding negotiated with the Accept-Encoding header.
+//
+// Responses smaller than the minimum size, already encoded, partial, or of
+// an excluded content type are written as they are. Routes opt out with
+// Disable, e.g. for Server-Sent Events:
+//
+//	m.Use(compress.New())
+//	m.HandleFunc(http.MethodGet, "/events", events, compress.Disable())
+package compress
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/josestg/mux"
+)
+
+type disabledKey struct{}
+
+// Disable is a route option applier opting the route out of compression.
+func Disable() mux.RouteOptionApplier {
+	return mux.WithValue(disabledKey{}, true)
+}
+
+// OptionApplier is a function for applying option.
+type OptionApplier func(o *Options)
+
+// Options holds the compression middleware optional fields.
+type Options struct {
+	// Encoders are the supported content codings. When the client accepts
+	// several of them with the same quality, the first one is used.
+	Encoders []Encoder
+
+	// MinSize is the size in bytes below which responses are not
+	// compressed, since the overhead outweighs the savings.
+	MinSize int
+
+	// ExcludedContentTypes are the media types which are not compressed,
+	// usually because they are compressed already. A type ending with "/*"
+	// excludes every subtype, e.g. image/*.
+	ExcludedContentTypes []string
+}
+
+// Default is a default option applier.
+func Default() OptionApplier {
+	return func(o *Options) {
+		o.Encoders = []Encoder{Gzip(gzip.DefaultCompression), Deflate(zlib.DefaultCompression)}
+		o.MinSize = 1024
+		o.ExcludedContentTypes = []string{
+			"image/*",
+			"audio/*",
+			"video/*",
+			"font/woff",
+			"font/woff2",
+			"application/gzip",
+			"application/zip",
+			"application/zstd",
+			"application/x-bzip2",
+			"application/x-7z-compressed",
+			"application/x-rar-compressed",
+			"application/pdf",
+			"application/octet-stream",
+			"text/event-stream",
+		}
+	}
+}
+
+// WithEncoder adds an Encoder, preferred over the previous ones on equal
+// quality, e.g. brotli over gzip.
+func WithEncoder(e Encoder) OptionApplier {
+	return func(o *Options) {
+		o.Encoders = append([]Encoder{e}, o.Encoders...)
+	}
+}
+
+// WithMinSize sets the MinSize.
+func WithMinSize(n int) OptionApplier {
+	return func(o *Options) {
+		o.MinSize = n
+	}
+}
+
+// WithExcludedContentTypes adds media types to the ExcludedContentTypes.
+func WithExcludedContentTypes(types ...string) OptionApplier {
+	return func(o *Options) {
+		o.ExcludedContentTypes = append(o.ExcludedContentTypes, types...)
+	}
+}
+
+// New creates a new compression middleware with Default option.
+//
+// The Vary: Accept-Encoding header is set on every response of the enabled
+// routes. The writer given to the handlers forwards http.Flusher and
+// http.Hijacker to the underlying writer: flushing compresses and sends what
+// was written so far.
+func New(appliers ...OptionApplier) mux.MiddlewareFunc {
+	var options Options
+	Default()(&options)
+
+	for _, apply := range appliers {
+		apply(&options)
+	}
+
+	offers := make([]string, len(options.Encoders))
+	for i, e := range options.Encoders {
+		offers[i] = e.Encoding()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rt := mux.GetRoute(r.Context()); rt != nil {
+				if disabled, _ := rt.Value(disabledKey{}).(bool); disabled {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			i := mux.NegotiateEncoding(r.Header.Get("Accept-Encoding"), offers)
+			if i < 0 || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				w:       w,
+				encoder: options.Encoders[i],
+				options: &options,
+			}
+			defer cw.close()
+
+			next.ServeHTTP(mux.HookResponseWriter(w, mux.ResponseWriterHooks{
+				WriteHeader: cw.writeHeader,
+				Write:       cw.write,
+				Flush:       cw.flush,
+				Hijack:      cw.hijack,
+			}), r)
+		})
+	}
+}
+
+// compressWriter buffers the beginning of the response until it can decide
+// whether to compress it.
+type compressWriter struct {
+	w       http.ResponseWriter
+	encoder Encoder
+	options *Options
+
+	status   int
+	buf      []byte
+	decided  bool
+	hijacked bool
+	zw       io.WriteCloser
+}
+
+// writeHeader records the status code written with the header once the
+// compression is decided. Informational 1xx status codes are passed through,
+// since more headers follow them.
+func (cw *compressWriter) writeHeader(code int) {
+	if code < http.StatusOK && code != http.StatusSwitchingProtocols {
+		cw.w.WriteHeader(code)
+		return
+	}
+
+	if cw.status != 0 || cw.decided {
+		return
+	}
+
+	cw.status = code
+}
+
+func (cw *compressWriter) write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.options.MinSize {
+			return len(b), nil
+		}
+
+		if err := cw.decide(false); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if cw.zw != nil {
+		return cw.zw.Write(b)
+	}
+	return cw.w.Write(b)
+}
+
+// decide writes the header, compressed or not, followed by the buffered
+// body. A flushed response is compressed regardless of its size so far,
+// since more is likely to follow.
+func (cw *compressWriter) decide(flushing bool) error {
+	cw.decided = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	if (flushing || len(cw.buf) >= cw.options.MinSize) && cw.compressible() {
+		h := cw.w.Header()
+		h.Set("Content-Encoding", cw.encoder.Encoding())
+		h.Del("Content-Length")
+
+		// a strong validator of the identity representation is not valid
+		// for the encoded one.
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+
+		cw.zw = cw.encoder.NewWriter(cw.w)
+	}
+
+	cw.w.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	if cw.zw != nil {
+		_, err := cw.zw.Write(buf)
+		return err
+	}
+
+	_, err := cw.w.Write(buf)
+	return err
+}
+
+func (cw *compressWriter) compressible() bool {
+	switch {
+	case cw.status < 200,
+		cw.status == http.StatusNoContent,
+		cw.status == http.StatusNotModified,
+		cw.status == http.StatusPartialContent:
+		return false
+	}
+
+	h := cw.w.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		ct = http.DetectContentType(cw.buf)
+		h.Set("Content-Type", ct)
+	}
+
+	return !excluded(cw.options.ExcludedContentTypes, ct)
+}
+
+func (cw *compressWriter) flush() {
+	if cw.hijacked {
+		return
+	}
+
+	if !cw.decided {
+		if err := cw.decide(true); err != nil {
+			return
+		}
+	}
+
+	if f, ok := cw.zw.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return
+		}
+	}
+
+	cw.w.(http.Flusher).Flush()
+}
+
+func (cw *compressWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := cw.w.(http.Hijacker).Hijack()
+	if err == nil {
+		cw.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// close writes what remains of the response.
+func (cw *compressWriter) close() {
+	if cw.hijacked {
+		return
+	}
+
+	if !cw.decided && cw.status == 0 {
+		return
+	}
+
+	if !cw.decided {
+		_ = cw.decide(false)
+	}
+
+	if cw.zw != nil {
+		_ = cw.zw.Close()
+	}
+}
+
+func excluded(types []string, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	for _, t := range types {
+		if prefix := strings.TrimSuffix(t, "*"); prefix != t {
+			if strings.HasPrefix(mediaType, prefix) {
+				return true
+			}
+			continue
+		}
+
+		if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,332 @@
+package compress_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/compress"
+)
+
+// reverseEncoder is a fake content coding reversing each write.
+type reverseEncoder struct{}
+
+func (reverseEncoder) Encoding() string { return "reverse" }
+
+func (reverseEncoder) NewWriter(w io.Writer) io.WriteCloser { return reverseWriter{w} }
+
+type reverseWriter struct{ w io.Writer }
+
+func (rw reverseWriter) Write(b []byte) (int, error) {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return rw.w.Write(r)
+}
+
+func (reverseWriter) Close() error { return nil }
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		r = zr
+	case "deflate":
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("zlib reader: %v", err)
+		}
+		r = zr
+	default:
+		return string(body)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decode %s: %v", encoding, err)
+	}
+	return string(b)
+}
+
+func TestMiddleware(t *testing.T) {
+	large := strings.Repeat("hello, world! ", 100)
+
+	m := mux.New()
+	m.Use(compress.New(compress.WithMinSize(64)))
+
+	text := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = io.WriteString(w, body)
+		}
+	}
+
+	m.HandleFunc(http.MethodGet, "/large", text(large))
+	m.HandleFunc(http.MethodGet, "/small", text("hello"))
+	m.HandleFunc(http.MethodGet, "/sniffed", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "<html>"+large+"</html>")
+	})
+	m.HandleFunc(http.MethodGet, "/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = io.WriteString(w, large)
+	})
+	m.HandleFunc(http.MethodGet, "/encoded", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		_, _ = io.WriteString(w, large)
+	})
+	m.HandleFunc(http.MethodGet, "/created", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusCreated)
+		for i := 0; i < 100; i++ {
+			_, _ = io.WriteString(w, "chunk ")
+		}
+	})
+	m.HandleFunc(http.MethodGet, "/disabled", text(large), compress.Disable())
+
+	tests := []struct {
+		path           string
+		accept         string
+		expStatusCode  int
+		expEncoding    string
+		expBody        string
+		expContentType string
+		expETag        string
+		expVary        string
+	}{
+		{path: "/large", accept: "gzip", expStatusCode: 200, expEncoding: "gzip", expBody: large, expVary: "Accept-Encoding"},
+		{path: "/large", accept: "deflate, gzip;q=0.5", expStatusCode: 200, expEncoding: "deflate", expBody: large, expVary: "Accept-Encoding"},
+		{path: "/large", accept: "*", expStatusCode: 200, expEncoding: "gzip", expBody: large, expVary: "Accept-Encoding"},
+		{path: "/large", accept: "gzip;q=0, identity", expStatusCode: 200, expBody: large, expVary: "Accept-Encoding"},
+		{path: "/large", accept: "", expStatusCode: 200, expBody: large, expVary: "Accept-Encoding"},
+		{path: "/small", accept: "gzip", expStatusCode: 200, expBody: "hello", expVary: "Accept-Encoding"},
+		{path: "/sniffed", accept: "gzip", expStatusCode: 200, expEncoding: "gzip", expBody: "<html>" + large + "</html>", expContentType: "text/html; charset=utf-8", expVary: "Accept-Encoding"},
+		{path: "/image", accept: "gzip", expStatusCode: 200, expBody: large, expVary: "Accept-Encoding"},
+		{path: "/encoded", accept: "gzip", expStatusCode: 200, expEncoding: "br", expBody: large, expVary: "Accept-Encoding"},
+		{path: "/created", accept: "gzip", expStatusCode: 201, expEncoding: "gzip", expBody: strings.Repeat("chunk ", 100), expETag: `W/"v1"`, expVary: "Accept-Encoding"},
+		{path: "/disabled", accept: "gzip", expStatusCode: 200, expBody: large},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.accept != "" {
+			r.Header.Set("Accept-Encoding", tc.accept)
+		}
+
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("%d: expected status code %d; got %d", i, tc.expStatusCode, rec.Code)
+		}
+
+		enc := rec.Header().Get("Content-Encoding")
+		if enc != tc.expEncoding {
+			t.Fatalf("%d: expected encoding %q; got %q", i, tc.expEncoding, enc)
+		}
+
+		if got := decode(t, enc, rec.Body.Bytes()); got != tc.expBody {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.expBody, got)
+		}
+
+		if tc.expContentType != "" && rec.Header().Get("Content-Type") != tc.expContentType {
+			t.Fatalf("%d: expected content type %q; got %q", i, tc.expContentType, rec.Header().Get("Content-Type"))
+		}
+
+		if got := rec.Header().Get("ETag"); got != tc.expETag {
+			t.Fatalf("%d: expected ETag %q; got %q", i, tc.expETag, got)
+		}
+
+		if got := rec.Header().Get("Vary"); got != tc.expVary {
+			t.Fatalf("%d: expected Vary %q; got %q", i, tc.expVary, got)
+		}
+
+		if enc == "gzip" && rec.Header().Get("Content-Length") != "" {
+			t.Fatalf("%d: expected no Content-Length", i)
+		}
+	}
+}
+
+func TestMiddleware_WithEncoder(t *testing.T) {
+	m := mux.New()
+	m.Use(compress.New(compress.WithMinSize(0), compress.WithEncoder(reverseEncoder{})))
+	m.HandleFunc(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, "abc")
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip, reverse")
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, r)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "reverse" {
+		t.Fatalf("expected encoding %q; got %q", "reverse", got)
+	}
+
+	if got := rec.Body.String(); got != "cba" {
+		t.Fatalf("expected body %q; got %q", "cba", got)
+	}
+}
+
+func TestMiddleware_Flush(t *testing.T) {
+	flushed := make(chan string, 1)
+
+	m := mux.New()
+	m.Use(compress.New())
+	m.HandleFunc(http.MethodGet, "/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = io.WriteString(w, "{\"n\":1}\n")
+		w.(http.Flusher).Flush()
+
+		flushed <- w.Header().Get("Content-Encoding")
+		_, _ = io.WriteString(w, "{\"n\":2}\n")
+	})
+
+	server := httptest.NewServer(m)
+	t.Cleanup(server.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := <-flushed; got != "gzip" {
+		t.Fatalf("expected flushed response encoded with gzip; got %q", got)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if got := decode(t, resp.Header.Get("Content-Encoding"), body); got != "{\"n\":1}\n{\"n\":2}\n" {
+		t.Fatalf("expected streamed body; got %q", got)
+	}
+}
+
+func TestMiddleware_Informational(t *testing.T) {
+	m := mux.New()
+	m.Use(compress.New(compress.WithMinSize(0)))
+	m.HandleFunc(http.MethodGet, "/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</app.css>; rel=preload")
+		w.WriteHeader(http.StatusEarlyHints)
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, "page")
+	})
+
+	server := httptest.NewServer(m)
+	t.Cleanup(server.Close)
+
+	var informational []int
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, _ textproto.MIMEHeader) error {
+			informational = append(informational, code)
+			return nil
+		},
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/page", nil)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if len(informational) != 1 || informational[0] != http.StatusEarlyHints {
+		t.Fatalf("expected informational status codes [103]; got %v", informational)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status code %d; got %d", http.StatusCreated, resp.StatusCode)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if got := decode(t, resp.Header.Get("Content-Encoding"), body); resp.Header.Get("Content-Encoding") != "gzip" || got != "page" {
+		t.Fatalf("expected body %q encoded with gzip; got %q encoded with %q", "page", got, resp.Header.Get("Content-Encoding"))
+	}
+}
+
+func TestMiddleware_Hijacker(t *testing.T) {
+	m := mux.New()
+	m.Use(compress.New())
+	m.HandleFunc(http.MethodGet, "/raw", func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("expected hijack; got %v", err)
+			return
+		}
+		defer conn.Close()
+
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 3\r\nConnection: close\r\n\r\nraw")
+		_ = rw.Flush()
+	})
+
+	server := httptest.NewServer(m)
+	t.Cleanup(server.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/raw", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "raw" {
+		t.Fatalf("expected body %q; got %q", "raw", body)
+	}
+}
+
+func TestDeflate(t *testing.T) {
+	var buf bytes.Buffer
+	w := compress.Deflate(zlib.BestSpeed).NewWriter(&buf)
+	_, _ = io.WriteString(w, "hello, ")
+	if err := w.(interface{ Flush() error }).Flush(); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	_, _ = io.WriteString(w, "world")
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	// the deflate content coding is the zlib format.
+	zr, err := zlib.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("expected zlib stream; got %v", err)
+	}
+
+	body, err := io.ReadAll(zr)
+	if err != nil || string(body) != "hello, world" {
+		t.Fatalf("expected %q; got %q, %v", "hello, world", body, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic on invalid level")
+		}
+	}()
+	compress.Deflate(42)
+}
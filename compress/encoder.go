@@ -0,0 +1,98 @@
+package compress
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"sync"
+)
+
+// Encoder creates the writers of a content coding. It is satisfied by
+// adapters of third-party implementations, e.g. for brotli:
+//
+//	type brotliEncoder struct{}
+//
+//	func (brotliEncoder) Encoding() string { return "br" }
+//
+//	func (brotliEncoder) NewWriter(w io.Writer) io.WriteCloser {
+//		return brotli.NewWriterLevel(w, brotli.DefaultCompression)
+//	}
+//
+// When the writer has a Flush() error method, it is called when the handler
+// flushes the response.
+type Encoder interface {
+	// Encoding returns the content coding, as used in the Accept-Encoding
+	// and Content-Encoding headers.
+	Encoding() string
+
+	// NewWriter returns a writer compressing into w. The response is
+	// complete once the writer is closed.
+	NewWriter(w io.Writer) io.WriteCloser
+}
+
+// Gzip returns an Encoder of the gzip content coding with the given
+// compression level, e.g. gzip.DefaultCompression. It panics when the level
+// is invalid.
+func Gzip(level int) Encoder {
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		panic(err)
+	}
+
+	e := &pooledEncoder{encoding: "gzip"}
+	e.pool.New = func() any {
+		zw, _ := gzip.NewWriterLevel(io.Discard, level)
+		return zw
+	}
+	return e
+}
+
+// Deflate returns an Encoder of the deflate content coding with the given
+// compression level, e.g. zlib.DefaultCompression. As defined by RFC 9110,
+// the deflate content coding is the zlib format, not raw deflate. It panics
+// when the level is invalid.
+func Deflate(level int) Encoder {
+	if _, err := zlib.NewWriterLevel(io.Discard, level); err != nil {
+		panic(err)
+	}
+
+	e := &pooledEncoder{encoding: "deflate"}
+	e.pool.New = func() any {
+		zw, _ := zlib.NewWriterLevel(io.Discard, level)
+		return zw
+	}
+	return e
+}
+
+// resetWriter is implemented by the writers of compress/gzip and
+// compress/zlib.
+type resetWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// pooledEncoder reuses its writers, since allocating them is expensive.
+type pooledEncoder struct {
+	encoding string
+	pool     sync.Pool
+}
+
+func (e *pooledEncoder) Encoding() string { return e.encoding }
+
+func (e *pooledEncoder) NewWriter(w io.Writer) io.WriteCloser {
+	zw := e.pool.Get().(resetWriter)
+	zw.Reset(w)
+	return &pooledWriter{resetWriter: zw, pool: &e.pool}
+}
+
+type pooledWriter struct {
+	resetWriter
+	pool *sync.Pool
+}
+
+func (w *pooledWriter) Close() error {
+	err := w.resetWriter.Close()
+	w.resetWriter.Reset(io.Discard)
+	w.pool.Put(w.resetWriter)
+	return err
+}
@@ -0,0 +1,275 @@
+// Package cors provides a Cross-Origin Resource Sharing middleware for Mux.
+//
+// Unlike generic CORS middlewares, preflight requests are answered with the
+// methods actually registered for the requested path, so routes do not need
+// an OPTIONS handler and preflights never reach the MethodNotFoundHandler.
+//
+// See: https://fetch.spec.whatwg.org/#http-cors-protocol.
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josestg/mux"
+)
+
+// CORS request and response headers.
+const (
+	HeaderOrigin           = "Origin"
+	HeaderVary             = "Vary"
+	HeaderRequestMethod    = "Access-Control-Request-Method"
+	HeaderRequestHeaders   = "Access-Control-Request-Headers"
+	HeaderAllowOrigin      = "Access-Control-Allow-Origin"
+	HeaderAllowMethods     = "Access-Control-Allow-Methods"
+	HeaderAllowHeaders     = "Access-Control-Allow-Headers"
+	HeaderAllowCredentials = "Access-Control-Allow-Credentials"
+	HeaderExposeHeaders    = "Access-Control-Expose-Headers"
+	HeaderMaxAge           = "Access-Control-Max-Age"
+)
+
+// OptionApplier is a function for applying option.
+type OptionApplier func(o *Options)
+
+// Options holds the CORS configuration.
+type Options struct {
+	// AllowedOrigins are the origins allowed to make cross-origin requests.
+	// An origin is either matched exactly, e.g. https://example.com, or by a
+	// single wildcard, e.g. https://*.example.com matches any subdomain of
+	// example.com. The "*" origin allows every origin, which is also the
+	// case when both AllowedOrigins and AllowOriginFunc are empty.
+	AllowedOrigins []string
+
+	// AllowOriginFunc is a predicate for origins that are not listed in the
+	// AllowedOrigins.
+	AllowOriginFunc func(origin string) bool
+
+	// AllowedHeaders are the request headers allowed in the actual request.
+	// When empty, the headers requested by the preflight are allowed.
+	AllowedHeaders []string
+
+	// ExposedHeaders are the response headers the browser exposes to the
+	// client.
+	ExposedHeaders []string
+
+	// AllowCredentials allows requests with cookies or HTTP authentication.
+	// It requires the allowed origins to be listed explicitly.
+	AllowCredentials bool
+
+	// MaxAge is how long the preflight result may be cached. Zero omits
+	// the header.
+	MaxAge time.Duration
+}
+
+// Default is a default option applier. It allows every origin without
+// credentials.
+func Default() OptionApplier {
+	return func(o *Options) {
+		o.AllowedOrigins = nil
+		o.AllowOriginFunc = nil
+		o.AllowedHeaders = nil
+		o.ExposedHeaders = nil
+		o.AllowCredentials = false
+		o.MaxAge = 0
+	}
+}
+
+// WithOrigins sets the allowed origins.
+func WithOrigins(origins ...string) OptionApplier {
+	return func(o *Options) {
+		o.AllowedOrigins = origins
+	}
+}
+
+// WithOriginFunc sets the origin predicate.
+func WithOriginFunc(fn func(origin string) bool) OptionApplier {
+	return func(o *Options) {
+		o.AllowOriginFunc = fn
+	}
+}
+
+// WithHeaders sets the allowed request headers.
+func WithHeaders(headers ...string) OptionApplier {
+	return func(o *Options) {
+		o.AllowedHeaders = headers
+	}
+}
+
+// WithExposedHeaders sets the exposed response headers.
+func WithExposedHeaders(headers ...string) OptionApplier {
+	return func(o *Options) {
+		o.ExposedHeaders = headers
+	}
+}
+
+// WithCredentials allows credentialed requests.
+func WithCredentials() OptionApplier {
+	return func(o *Options) {
+		o.AllowCredentials = true
+	}
+}
+
+// WithMaxAge sets how long preflight results may be cached.
+func WithMaxAge(d time.Duration) OptionApplier {
+	return func(o *Options) {
+		o.MaxAge = d
+	}
+}
+
+// New creates a new CORS middleware with Default option. It panics when
+// credentials are allowed for every origin, since any site could then make
+// credentialed requests on behalf of the user.
+func New(appliers ...OptionApplier) mux.MiddlewareFunc {
+	var options Options
+	Default()(&options)
+
+	for _, apply := range appliers {
+		apply(&options)
+	}
+
+	c := newCors(&options)
+	if c.allowAll && options.AllowCredentials {
+		panic(errors.New("credentials require an explicit list of allowed origins"))
+	}
+	return c.middleware
+}
+
+type cors struct {
+	options   *Options
+	allowAll  bool
+	exact     map[string]struct{}
+	wildcards []wildcard
+	headers   string
+	exposed   string
+}
+
+type wildcard struct {
+	prefix string
+	suffix string
+}
+
+func (w wildcard) match(origin string) bool {
+	return len(origin) > len(w.prefix)+len(w.suffix) &&
+		strings.HasPrefix(origin, w.prefix) &&
+		strings.HasSuffix(origin, w.suffix)
+}
+
+func newCors(options *Options) *cors {
+	c := &cors{
+		options:  options,
+		allowAll: len(options.AllowedOrigins) == 0 && options.AllowOriginFunc == nil,
+		exact:    make(map[string]struct{}),
+		headers:  strings.Join(options.AllowedHeaders, ", "),
+		exposed:  strings.Join(options.ExposedHeaders, ", "),
+	}
+
+	for _, origin := range options.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		switch i := strings.IndexByte(origin, '*'); {
+		case origin == "*":
+			c.allowAll = true
+		case i >= 0:
+			c.wildcards = append(c.wildcards, wildcard{prefix: origin[:i], suffix: origin[i+1:]})
+		default:
+			c.exact[origin] = struct{}{}
+		}
+	}
+
+	return c
+}
+
+func (c *cors) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get(HeaderOrigin)
+		preflight := r.Method == http.MethodOptions && r.Header.Get(HeaderRequestMethod) != ""
+
+		if preflight {
+			c.preflight(w, r, next)
+			return
+		}
+
+		w.Header().Add(HeaderVary, HeaderOrigin)
+		if origin != "" && c.allowed(origin) {
+			h := w.Header()
+			h.Set(HeaderAllowOrigin, c.allowOrigin(origin))
+			if c.options.AllowCredentials {
+				h.Set(HeaderAllowCredentials, "true")
+			}
+			if c.exposed != "" {
+				h.Set(HeaderExposeHeaders, c.exposed)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	methods := mux.GetAllowedMethods(r.Context())
+	if methods == nil {
+		// the path is not registered, let the router answer with 404.
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	h := w.Header()
+	h.Add(HeaderVary, HeaderOrigin)
+	h.Add(HeaderVary, HeaderRequestMethod)
+	h.Add(HeaderVary, HeaderRequestHeaders)
+
+	origin := r.Header.Get(HeaderOrigin)
+	if origin == "" || !c.allowed(origin) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	h.Set(HeaderAllowOrigin, c.allowOrigin(origin))
+	h.Set(HeaderAllowMethods, strings.Join(methods, ", "))
+
+	if c.headers != "" {
+		h.Set(HeaderAllowHeaders, c.headers)
+	} else if requested := r.Header.Get(HeaderRequestHeaders); requested != "" {
+		h.Set(HeaderAllowHeaders, requested)
+	}
+
+	if c.options.AllowCredentials {
+		h.Set(HeaderAllowCredentials, "true")
+	}
+
+	if c.options.MaxAge > 0 {
+		h.Set(HeaderMaxAge, strconv.Itoa(int(c.options.MaxAge/time.Second)))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *cors) allowed(origin string) bool {
+	if c.allowAll {
+		return true
+	}
+
+	lower := strings.ToLower(origin)
+	if _, ok := c.exact[lower]; ok {
+		return true
+	}
+
+	for _, w := range c.wildcards {
+		if w.match(lower) {
+			return true
+		}
+	}
+
+	return c.options.AllowOriginFunc != nil && c.options.AllowOriginFunc(origin)
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value. The "*" value
+// is not allowed for credentialed requests, so the origin is echoed instead.
+func (c *cors) allowOrigin(origin string) string {
+	if c.allowAll && !c.options.AllowCredentials {
+		return "*"
+	}
+	return origin
+}
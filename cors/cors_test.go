@@ -0,0 +1,191 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/cors"
+)
+
+func newMux(appliers ...cors.OptionApplier) *mux.Mux {
+	m := mux.New()
+	m.Use(cors.New(appliers...))
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	m.HandleFunc(http.MethodGet, "/books", ok)
+	m.HandleFunc(http.MethodPost, "/books", ok)
+	m.HandleFunc(http.MethodDelete, "/books/:id", ok)
+	return m
+}
+
+func preflight(path, origin, method string) *http.Request {
+	r := httptest.NewRequest(http.MethodOptions, path, nil)
+	r.Header.Set(cors.HeaderOrigin, origin)
+	r.Header.Set(cors.HeaderRequestMethod, method)
+	return r
+}
+
+func TestPreflight(t *testing.T) {
+	m := newMux(
+		cors.WithOrigins("https://example.com", "https://*.example.org"),
+		cors.WithOriginFunc(func(origin string) bool { return strings.HasSuffix(origin, ".test") }),
+		cors.WithCredentials(),
+		cors.WithMaxAge(10*time.Minute),
+	)
+
+	tests := []struct {
+		path           string
+		origin         string
+		expStatusCode  int
+		expAllowOrigin string
+		expMethods     string
+	}{
+		{
+			path:           "/books",
+			origin:         "https://example.com",
+			expStatusCode:  http.StatusNoContent,
+			expAllowOrigin: "https://example.com",
+			expMethods:     "GET, POST",
+		},
+		{
+			path:           "/books/1",
+			origin:         "https://api.example.org",
+			expStatusCode:  http.StatusNoContent,
+			expAllowOrigin: "https://api.example.org",
+			expMethods:     "DELETE",
+		},
+		{
+			path:           "/books",
+			origin:         "http://localhost.test",
+			expStatusCode:  http.StatusNoContent,
+			expAllowOrigin: "http://localhost.test",
+			expMethods:     "GET, POST",
+		},
+		{
+			path:          "/books",
+			origin:        "https://example.org",
+			expStatusCode: http.StatusForbidden,
+		},
+		{
+			path:          "/books",
+			origin:        "https://evil.com",
+			expStatusCode: http.StatusForbidden,
+		},
+		{
+			path:          "/unknown",
+			origin:        "https://example.com",
+			expStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, preflight(tc.path, tc.origin, http.MethodPost))
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("%s %s: expected status %d; got %d", tc.path, tc.origin, tc.expStatusCode, rec.Code)
+		}
+
+		h := rec.Header()
+		if got := h.Get(cors.HeaderAllowOrigin); got != tc.expAllowOrigin {
+			t.Fatalf("%s %s: expected allow origin %q; got %q", tc.path, tc.origin, tc.expAllowOrigin, got)
+		}
+
+		if got := h.Get(cors.HeaderAllowMethods); got != tc.expMethods {
+			t.Fatalf("%s %s: expected allow methods %q; got %q", tc.path, tc.origin, tc.expMethods, got)
+		}
+
+		if tc.expStatusCode != http.StatusNoContent {
+			continue
+		}
+
+		if got := h.Get(cors.HeaderAllowCredentials); got != "true" {
+			t.Fatalf("expected allow credentials %q; got %q", "true", got)
+		}
+
+		if got := h.Get(cors.HeaderMaxAge); got != "600" {
+			t.Fatalf("expected max age %q; got %q", "600", got)
+		}
+	}
+}
+
+func TestPreflight_AllowedHeaders(t *testing.T) {
+	r := preflight("/books", "https://example.com", http.MethodPost)
+	r.Header.Set(cors.HeaderRequestHeaders, "Content-Type, X-Request-ID")
+
+	rec := httptest.NewRecorder()
+	newMux().ServeHTTP(rec, r)
+
+	if got := rec.Header().Get(cors.HeaderAllowHeaders); got != "Content-Type, X-Request-ID" {
+		t.Fatalf("expected requested headers to be reflected; got %q", got)
+	}
+
+	if got := rec.Header().Get(cors.HeaderAllowOrigin); got != "*" {
+		t.Fatalf("expected allow origin %q; got %q", "*", got)
+	}
+
+	rec = httptest.NewRecorder()
+	newMux(cors.WithHeaders("Content-Type")).ServeHTTP(rec, r)
+
+	if got := rec.Header().Get(cors.HeaderAllowHeaders); got != "Content-Type" {
+		t.Fatalf("expected allow headers %q; got %q", "Content-Type", got)
+	}
+}
+
+func TestActualRequest(t *testing.T) {
+	m := newMux(cors.WithOrigins("https://example.com"), cors.WithExposedHeaders("X-Total-Count"))
+
+	r := httptest.NewRequest(http.MethodGet, "/books", nil)
+	r.Header.Set(cors.HeaderOrigin, "https://example.com")
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, r)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d; got %d", http.StatusOK, rec.Code)
+	}
+
+	h := rec.Header()
+	if got := h.Get(cors.HeaderAllowOrigin); got != "https://example.com" {
+		t.Fatalf("expected allow origin %q; got %q", "https://example.com", got)
+	}
+
+	if got := h.Get(cors.HeaderExposeHeaders); got != "X-Total-Count" {
+		t.Fatalf("expected expose headers %q; got %q", "X-Total-Count", got)
+	}
+
+	if got := h.Get(cors.HeaderVary); got != cors.HeaderOrigin {
+		t.Fatalf("expected vary %q; got %q", cors.HeaderOrigin, got)
+	}
+
+	r.Header.Set(cors.HeaderOrigin, "https://evil.com")
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, r)
+
+	if got := rec.Header().Get(cors.HeaderAllowOrigin); got != "" {
+		t.Fatalf("expected no allow origin; got %q", got)
+	}
+}
+
+func TestNew_CredentialsForEveryOrigin(t *testing.T) {
+	tests := [][]cors.OptionApplier{
+		{cors.WithCredentials()},
+		{cors.WithCredentials(), cors.WithOrigins("https://example.com", "*")},
+	}
+
+	for i, appliers := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%d: expected panic", i)
+				}
+			}()
+
+			cors.New(appliers...)
+		}()
+	}
+}
@@ -0,0 +1,97 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/josestg/mux/internal/trie"
+)
+
+// Deprecation describes the retirement of a deprecated Route.
+type Deprecation struct {
+	// At is when the Route was deprecated.
+	At time.Time
+
+	// Sunset is when the Route stops being served. Zero when it is not
+	// planned.
+	Sunset time.Time
+
+	// Successor is the name given by WithName to the Route replacing it.
+	// Empty when it has no replacement.
+	Successor string
+}
+
+// DeprecationHook is called for every request served by a deprecated Route,
+// before the middlewares run. It lets the calls be counted until the Route
+// can be removed.
+type DeprecationHook func(r *http.Request, rt *Route)
+
+// WithDeprecation is a route option applier marking the Route deprecated
+// since the given time. Its responses carry the Deprecation and Sunset
+// headers and, when the successor names a registered route, a Link header
+// to it with the successor-version relation:
+//
+//	m.Handle(http.MethodGet, "/books/:id", getBookV2, mux.WithName("books.get.v2"))
+//	m.Handle(http.MethodGet, "/book/:id", getBook,
+//		mux.WithDeprecation(deprecatedAt, sunsetAt, "books.get.v2"),
+//	)
+//
+// The variables of the successor pattern are filled with the ones of the
+// request, so GET /book/1 links to </books/1>. A zero sunset and an empty
+// successor are omitted.
+func WithDeprecation(at time.Time, sunset time.Time, successor string) RouteOptionApplier {
+	return func(rt *Route) {
+		rt.Deprecation = &Deprecation{At: at, Sunset: sunset, Successor: successor}
+	}
+}
+
+// deprecate sets the deprecation headers of the route and calls the
+// DeprecationHook.
+func (m *Mux) deprecate(w http.ResponseWriter, r *http.Request, rt *Route) {
+	d := rt.Deprecation
+	setDeprecationHeaders(w.Header(), d.At, d.Sunset)
+
+	if d.Successor != "" {
+		if successor := m.findRoute(d.Successor); successor != nil {
+			link := expandPattern(successor.VersionedPattern(), GetVars(r.Context()))
+			w.Header().Add("Link", "<"+link+`>; rel="successor-version"`)
+		}
+	}
+
+	if m.options.DeprecationHook != nil {
+		m.options.DeprecationHook(r, rt)
+	}
+}
+
+// findRoute returns the route with the name, looked up in the routes of the
+// Mux, then in the versions from the newest. It returns nil when no route
+// has the name.
+func (m *Mux) findRoute(name string) *Route {
+	if rt, ok := m.table.names[name]; ok {
+		return &rt.Route
+	}
+
+	for i := len(m.versions) - 1; i >= 0; i-- {
+		if rt, ok := m.versions[i].table.names[name]; ok {
+			return &rt.Route
+		}
+	}
+	return nil
+}
+
+// expandPattern replaces the variables of the pattern by their values. The
+// variables without value are kept as is.
+func expandPattern(pattern string, vars trie.Vars) string {
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, ":") && !strings.HasPrefix(seg, "*") {
+			continue
+		}
+
+		if v, ok := vars[seg[1:]]; ok {
+			segments[i] = v
+		}
+	}
+	return strings.Join(segments, "/")
+}
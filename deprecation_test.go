@@ -0,0 +1,71 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+)
+
+func TestWithDeprecation(t *testing.T) {
+	deprecatedAt := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunsetAt := time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	calls := make(map[string]int)
+	handler := mux.New(mux.WithDeprecationHook(func(r *http.Request, rt *mux.Route) {
+		calls[rt.Pattern+" "+mux.GetVars(r.Context())["id"]]++
+	}))
+
+	handler.Handle(http.MethodGet, "/books/:id", named("book"), mux.WithName("books.get"))
+	handler.Handle(http.MethodGet, "/book/:id", named("old book"), mux.WithDeprecation(deprecatedAt, sunsetAt, "books.get"))
+	handler.Handle(http.MethodGet, "/authors", named("old authors"), mux.WithDeprecation(deprecatedAt, time.Time{}, "authors.list"))
+	handler.Handle(http.MethodGet, "/shelves", named("old shelves"), mux.WithDeprecation(deprecatedAt, time.Time{}, "unknown"))
+	handler.Version("v2").Handle(http.MethodGet, "/authors", named("authors"), mux.WithName("authors.list"))
+
+	tests := []struct {
+		path           string
+		expBody        string
+		expDeprecation string
+		expSunset      string
+		expLink        string
+	}{
+		{path: "/books/1", expBody: "book"},
+		{path: "/book/1", expBody: "old book", expDeprecation: "@1767225600", expSunset: "Thu, 31 Dec 2026 00:00:00 GMT", expLink: `</books/1>; rel="successor-version"`},
+		{path: "/authors", expBody: "old authors", expDeprecation: "@1767225600", expLink: `</v2/authors>; rel="successor-version"`},
+		{path: "/shelves", expBody: "old shelves", expDeprecation: "@1767225600"},
+	}
+
+	for i, tc := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if rec.Body.String() != tc.expBody {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.expBody, rec.Body.String())
+		}
+
+		if got := rec.Header().Get("Deprecation"); got != tc.expDeprecation {
+			t.Fatalf("%d: expected Deprecation %q; got %q", i, tc.expDeprecation, got)
+		}
+
+		if got := rec.Header().Get("Sunset"); got != tc.expSunset {
+			t.Fatalf("%d: expected Sunset %q; got %q", i, tc.expSunset, got)
+		}
+
+		if got := rec.Header().Get("Link"); got != tc.expLink {
+			t.Fatalf("%d: expected Link %q; got %q", i, tc.expLink, got)
+		}
+	}
+
+	expCalls := map[string]int{"/book/:id 1": 1, "/authors ": 1, "/shelves ": 1}
+	if len(calls) != len(expCalls) {
+		t.Fatalf("expected calls %v; got %v", expCalls, calls)
+	}
+
+	for k, v := range expCalls {
+		if calls[k] != v {
+			t.Fatalf("expected calls %v; got %v", expCalls, calls)
+		}
+	}
+}
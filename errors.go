@@ -0,0 +1,122 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// HandlerE is like http.HandlerFunc but returns an error. The returned error
+// is rendered by the ErrorHandler of the Mux, so the handler must not write
+// the response when it returns an error.
+type HandlerE func(w http.ResponseWriter, r *http.Request) error
+
+// ErrorHandler writes the response of an error returned by a HandlerE.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// HandleE registers the HandlerE for the given HTTP method and URL path.
+func (m *Mux) HandleE(method string, path string, handler HandlerE, appliers ...RouteOptionApplier) {
+	m.Handle(method, path, m.handlerE(handler), appliers...)
+}
+
+// handlerE adapts the HandlerE to an http.Handler rendering its errors with
+// the ErrorHandler.
+func (m *Mux) handlerE(handler HandlerE) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := NewResponseWriter(w)
+		if err := handler(rw, r); err != nil && !rw.Written() {
+			m.options.ErrorHandler(w, r, err)
+		}
+	})
+}
+
+// StatusCoder is implemented by errors which know the HTTP status code of
+// their response.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// HTTPError is an error carrying the HTTP status code of the response.
+type HTTPError struct {
+	// Status is the HTTP status code.
+	Status int
+
+	// Detail is the message shown to the client.
+	Detail string
+
+	// Err is the underlying error, it is not shown to the client.
+	Err error
+}
+
+// NewHTTPError creates a new HTTPError.
+func NewHTTPError(status int, detail string) *HTTPError {
+	return &HTTPError{Status: status, Detail: detail}
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = http.StatusText(e.Status)
+	}
+
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode implements the StatusCoder interface.
+func (e *HTTPError) StatusCode() int {
+	return e.Status
+}
+
+// Wrap returns a copy of e with err as the underlying error.
+func (e *HTTPError) Wrap(err error) *HTTPError {
+	cp := *e
+	cp.Err = err
+	return &cp
+}
+
+// StatusCode returns the HTTP status code of err. Errors implementing
+// StatusCoder use their own status code when it is a 4xx or 5xx one,
+// ErrRouteNotFound maps to 404, ErrMethodNotAllowed to 405, ErrBodyTooLarge
+// to 413, context.DeadlineExceeded to 503, and every other error to 500.
+func StatusCode(err error) int {
+	var sc StatusCoder
+	switch {
+	case errors.As(err, &sc):
+		if code := sc.StatusCode(); code >= 400 && code <= 599 {
+			return code
+		}
+		return http.StatusInternalServerError
+	case errors.Is(err, ErrRouteNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrMethodNotAllowed):
+		return http.StatusMethodNotAllowed
+	case errors.Is(err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// DefaultErrorHandler renders err as an RFC 9457 problem+json response with
+// the status code given by StatusCode. Only the Detail of an HTTPError is
+// shown to the client, other error messages may leak internals.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	var detail string
+	var he *HTTPError
+	if errors.As(err, &he) {
+		detail = he.Detail
+	}
+
+	WriteProblem(w, NewProblem(r, StatusCode(err), detail))
+}
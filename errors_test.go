@@ -0,0 +1,172 @@
+package mux_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/josestg/mux"
+)
+
+type teapotError struct{}
+
+func (teapotError) Error() string   { return "short and stout" }
+func (teapotError) StatusCode() int { return http.StatusTeapot }
+
+func TestMux_HandleE(t *testing.T) {
+	handler := mux.New()
+
+	handler.HandleE(http.MethodGet, "/books/:id", func(w http.ResponseWriter, r *http.Request) error {
+		switch id := mux.GetVars(r.Context()).Get("id"); id {
+		case "0":
+			return mux.NewHTTPError(http.StatusNotFound, "book 0 is not found")
+		case "teapot":
+			return fmt.Errorf("brewing: %w", teapotError{})
+		case "internal":
+			return errors.New("database password is hunter2")
+		case "invalid":
+			return mux.NewHTTPError(0, "book status is unknown")
+		case "ok":
+			return mux.NewHTTPError(http.StatusOK, "book is fine")
+		case "found":
+			return mux.NewHTTPError(http.StatusFound, "book has moved")
+		case "written":
+			w.WriteHeader(http.StatusAccepted)
+			return errors.New("too late to render")
+		default:
+			w.WriteHeader(http.StatusOK)
+			return nil
+		}
+	})
+
+	tests := []struct {
+		path          string
+		expStatusCode int
+		expProblem    *mux.Problem
+	}{
+		{path: "/books/1", expStatusCode: http.StatusOK},
+		{path: "/books/written", expStatusCode: http.StatusAccepted},
+		{
+			path:          "/books/0",
+			expStatusCode: http.StatusNotFound,
+			expProblem: &mux.Problem{
+				Type:     "about:blank",
+				Title:    "Not Found",
+				Status:   http.StatusNotFound,
+				Detail:   "book 0 is not found",
+				Instance: "/books/0",
+			},
+		},
+		{
+			path:          "/books/teapot",
+			expStatusCode: http.StatusTeapot,
+			expProblem: &mux.Problem{
+				Type:     "about:blank",
+				Title:    "I'm a teapot",
+				Status:   http.StatusTeapot,
+				Instance: "/books/teapot",
+			},
+		},
+		{
+			path:          "/books/internal",
+			expStatusCode: http.StatusInternalServerError,
+			expProblem: &mux.Problem{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Instance: "/books/internal",
+			},
+		},
+		{
+			path:          "/books/invalid",
+			expStatusCode: http.StatusInternalServerError,
+			expProblem: &mux.Problem{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "book status is unknown",
+				Instance: "/books/invalid",
+			},
+		},
+		{
+			path:          "/books/ok",
+			expStatusCode: http.StatusInternalServerError,
+			expProblem: &mux.Problem{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "book is fine",
+				Instance: "/books/ok",
+			},
+		},
+		{
+			path:          "/books/found",
+			expStatusCode: http.StatusInternalServerError,
+			expProblem: &mux.Problem{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "book has moved",
+				Instance: "/books/found",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("%s: expected %d; got %d", tc.path, tc.expStatusCode, rec.Code)
+		}
+
+		if tc.expProblem == nil {
+			continue
+		}
+
+		if got := rec.Header().Get("Content-Type"); got != mux.ProblemContentType {
+			t.Fatalf("%s: expected content type %q; got %q", tc.path, mux.ProblemContentType, got)
+		}
+
+		var got mux.Problem
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(&got, tc.expProblem) {
+			t.Fatalf("%s: expected %+v; got %+v", tc.path, tc.expProblem, got)
+		}
+	}
+}
+
+func TestMux_ErrorHandler(t *testing.T) {
+	var got error
+	handler := mux.New(mux.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		got = err
+		w.WriteHeader(mux.StatusCode(err))
+	}))
+
+	exp := mux.NewHTTPError(http.StatusConflict, "duplicate").Wrap(errors.New("unique violation"))
+	handler.HandleE(http.MethodPost, "/books", func(w http.ResponseWriter, r *http.Request) error {
+		return exp
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/books", nil))
+
+	if got != exp {
+		t.Fatalf("expected %v; got %v", exp, got)
+	}
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected %d; got %d", http.StatusConflict, rec.Code)
+	}
+
+	if msg := exp.Error(); msg != "duplicate: unique violation" {
+		t.Fatalf("expected %q; got %q", "duplicate: unique violation", msg)
+	}
+}
@@ -0,0 +1,223 @@
+// Package etag provides a Mux middleware which tags responses with entity
+// tags and answers conditional requests.
+//
+// The middleware buffers the successful GET and HEAD responses, derives an
+// ETag from their body unless the handler set one, and answers
+// If-None-Match and If-Modified-Since with 304 Not Modified.
+//
+// Routes which know the validator of the resource without rendering it,
+// e.g. from a version column, supply it with WithResolver. The middleware
+// then answers the conditional requests before the handler runs: the
+// conditional GET requests with 304 Not Modified, and If-Match and
+// If-Unmodified-Since of unsafe methods with 412 Precondition Failed:
+//
+//	m.Use(etag.New())
+//	m.HandleFunc(http.MethodPut, "/books/:id", updateBook,
+//		etag.WithResolver(func(r *http.Request) (etag.Validator, error) {
+//			version, err := store.BookVersion(r.Context(), mux.GetVars(r.Context()).Get("id"))
+//			return etag.Validator{ETag: etag.Strong(version)}, err
+//		}),
+//	)
+//
+// Handlers which load the resource anyway call Validate instead:
+//
+//	func updateBook(w http.ResponseWriter, r *http.Request) {
+//		book := load(r)
+//		if !etag.Validate(w, r, etag.Validator{ETag: etag.Strong(book.Version)}) {
+//			return
+//		}
+//		...
+//	}
+//
+// See: https://www.rfc-editor.org/rfc/rfc9110#name-conditional-requests.
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/josestg/mux"
+)
+
+// Strong returns the strong entity tag of the opaque value, e.g. a version.
+func Strong(v string) string {
+	return `"` + v + `"`
+}
+
+// Weak returns the weak entity tag of the opaque value, for representations
+// which are only semantically equivalent.
+func Weak(v string) string {
+	return `W/"` + v + `"`
+}
+
+// Validator holds the validators of the current representation of a
+// resource. The zero values are unknown validators.
+type Validator struct {
+	// ETag is the entity tag, created by Strong or Weak.
+	ETag string
+
+	// LastModified is the time of the last modification.
+	LastModified time.Time
+}
+
+type resolverKey struct{}
+
+// Resolver returns the validator of the current representation of the
+// resource targeted by the request. The zero Validator means that the
+// resource has no current representation. The errors are rendered with the
+// status code given by mux.StatusCode.
+type Resolver func(r *http.Request) (Validator, error)
+
+// WithResolver is a route option applier letting the middleware evaluate the
+// preconditions of the conditional requests before the handler of the route
+// runs, for every method.
+func WithResolver(fn Resolver) mux.RouteOptionApplier {
+	return mux.WithValue(resolverKey{}, fn)
+}
+
+// precondition answers the conditional request with the Resolver of the
+// route, and reports whether the handler should run.
+func precondition(w http.ResponseWriter, r *http.Request) bool {
+	rt := mux.GetRoute(r.Context())
+	if rt == nil || !conditional(r) {
+		return true
+	}
+
+	fn, ok := rt.Value(resolverKey{}).(Resolver)
+	if !ok {
+		return true
+	}
+
+	v, err := fn(r)
+	if err != nil {
+		mux.Error(w, r, mux.StatusCode(err))
+		return false
+	}
+
+	// the validators are only sent with the answers, the handler of an
+	// unsafe method changes the representation.
+	if evaluate(r, v) == 0 {
+		return true
+	}
+	return Validate(w, r, v)
+}
+
+func conditional(r *http.Request) bool {
+	for _, k := range []string{"If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since"} {
+		if r.Header.Get(k) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate sets the validator headers of the response and evaluates the
+// preconditions of r against v. When a precondition fails, it writes 304
+// Not Modified or 412 Precondition Failed and returns false, and the handler
+// should return without writing the response.
+func Validate(w http.ResponseWriter, r *http.Request, v Validator) bool {
+	h := w.Header()
+	if v.ETag != "" {
+		h.Set("ETag", v.ETag)
+	}
+	if !v.LastModified.IsZero() {
+		h.Set("Last-Modified", v.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	switch evaluate(r, v) {
+	case http.StatusNotModified:
+		writeNotModified(w)
+		return false
+	case http.StatusPreconditionFailed:
+		mux.Error(w, r, http.StatusPreconditionFailed)
+		return false
+	default:
+		return true
+	}
+}
+
+// evaluate returns the status code answering the preconditions of r, or zero
+// when the request should be served.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9110#name-precedence-of-preconditions.
+func evaluate(r *http.Request, v Validator) int {
+	safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	if im := r.Header.Get("If-Match"); im != "" {
+		if !matches(im, v.ETag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if t, ok := parseTime(r.Header.Get("If-Unmodified-Since")); ok && !v.LastModified.IsZero() {
+		if v.LastModified.Truncate(time.Second).After(t) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if matches(inm, v.ETag, true) {
+			if safe {
+				return http.StatusNotModified
+			}
+			return http.StatusPreconditionFailed
+		}
+	} else if t, ok := parseTime(r.Header.Get("If-Modified-Since")); ok && safe && !v.LastModified.IsZero() {
+		if !v.LastModified.Truncate(time.Second).After(t) {
+			return http.StatusNotModified
+		}
+	}
+
+	return 0
+}
+
+// matches reports whether the list of entity tags of a conditional header
+// matches etag, using the weak comparison for If-None-Match and the strong
+// comparison for If-Match.
+func matches(list, etag string, weak bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return etag != ""
+	}
+
+	if etag == "" || (!weak && strings.HasPrefix(etag, "W/")) {
+		return false
+	}
+
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		if !weak && strings.HasPrefix(tag, "W/") {
+			continue
+		}
+
+		if strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTime(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+
+	t, err := http.ParseTime(s)
+	return t, err == nil
+}
+
+func writeNotModified(w http.ResponseWriter) {
+	// a 304 describes the selected representation with the validators
+	// only.
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// hash returns the strong entity tag of the body.
+func hash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return Strong(base64.RawURLEncoding.EncodeToString(sum[:18]))
+}
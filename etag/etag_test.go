@@ -0,0 +1,237 @@
+package etag_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/etag"
+)
+
+func TestMiddleware(t *testing.T) {
+	var calls int
+	m := mux.New()
+	m.Use(etag.New(etag.WithMaxSize(64)))
+	m.HandleFunc(http.MethodGet, "/books/:id", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":`+mux.GetVars(r.Context()).Get("id")+`}`)
+	})
+	m.HandleFunc(http.MethodGet, "/large", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("a", 100))
+	})
+	m.HandleFunc(http.MethodGet, "/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "missing", http.StatusNotFound)
+	})
+
+	get := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := get("/books/1", nil)
+	tag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || tag == "" || strings.HasPrefix(tag, "W/") {
+		t.Fatalf("expected 200 with a strong ETag; got %d %q", rec.Code, tag)
+	}
+
+	if rec.Body.String() != `{"id":1}` {
+		t.Fatalf("expected body %q; got %q", `{"id":1}`, rec.Body.String())
+	}
+
+	tests := []struct {
+		path          string
+		header        map[string]string
+		expStatusCode int
+		expETag       bool
+	}{
+		{path: "/books/1", header: map[string]string{"If-None-Match": tag}, expStatusCode: 304, expETag: true},
+		{path: "/books/1", header: map[string]string{"If-None-Match": `"other", W/` + tag}, expStatusCode: 304, expETag: true},
+		{path: "/books/1", header: map[string]string{"If-None-Match": "*"}, expStatusCode: 304, expETag: true},
+		{path: "/books/2", header: map[string]string{"If-None-Match": tag}, expStatusCode: 200, expETag: true},
+		{path: "/large", header: map[string]string{"If-None-Match": "*"}, expStatusCode: 200},
+		{path: "/missing", header: map[string]string{"If-None-Match": "*"}, expStatusCode: 404},
+		{path: "/books/1", header: map[string]string{"If-Match": tag}, expStatusCode: 200, expETag: true},
+		{path: "/books/1", header: map[string]string{"If-Match": `"other"`}, expStatusCode: 412, expETag: true},
+	}
+
+	for i, tc := range tests {
+		rec := get(tc.path, tc.header)
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("%d: expected status code %d; got %d", i, tc.expStatusCode, rec.Code)
+		}
+
+		if got := rec.Header().Get("ETag") != ""; got != tc.expETag {
+			t.Fatalf("%d: expected ETag %v; got %q", i, tc.expETag, rec.Header().Get("ETag"))
+		}
+
+		if rec.Code == http.StatusNotModified && (rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "") {
+			t.Fatalf("%d: expected an empty 304; got %q %v", i, rec.Body.String(), rec.Header())
+		}
+	}
+
+	if calls != 7 {
+		t.Fatalf("expected 7 calls; got %d", calls)
+	}
+}
+
+func TestMiddleware_Weak(t *testing.T) {
+	m := mux.New()
+	m.Use(etag.New(etag.WithWeak(true)))
+	m.HandleFunc(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if tag := rec.Header().Get("ETag"); !strings.HasPrefix(tag, `W/"`) {
+		t.Fatalf("expected weak ETag; got %q", tag)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	v := etag.Validator{ETag: etag.Strong("v2"), LastModified: modified}
+
+	var calls int
+	m := mux.New()
+	m.Use(etag.New())
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if !etag.Validate(w, r, v) {
+			return
+		}
+		calls++
+		_, _ = io.WriteString(w, "book")
+	}
+	m.HandleFunc(http.MethodGet, "/books/:id", handler)
+	m.HandleFunc(http.MethodPut, "/books/:id", handler)
+	m.HandleFunc(http.MethodDelete, "/books/:id", handler)
+
+	before := modified.Add(-time.Hour).Format(http.TimeFormat)
+	after := modified.Add(time.Hour).Format(http.TimeFormat)
+
+	tests := []struct {
+		method        string
+		header        map[string]string
+		expStatusCode int
+	}{
+		{method: http.MethodGet, expStatusCode: 200},
+		{method: http.MethodGet, header: map[string]string{"If-None-Match": `"v2"`}, expStatusCode: 304},
+		{method: http.MethodGet, header: map[string]string{"If-None-Match": `"v1"`}, expStatusCode: 200},
+		{method: http.MethodGet, header: map[string]string{"If-Modified-Since": after}, expStatusCode: 304},
+		{method: http.MethodGet, header: map[string]string{"If-Modified-Since": before}, expStatusCode: 200},
+		// If-None-Match takes precedence over If-Modified-Since.
+		{method: http.MethodGet, header: map[string]string{"If-None-Match": `"v1"`, "If-Modified-Since": after}, expStatusCode: 200},
+		{method: http.MethodPut, header: map[string]string{"If-Match": `"v2"`}, expStatusCode: 200},
+		{method: http.MethodPut, header: map[string]string{"If-Match": `"v1"`}, expStatusCode: 412},
+		{method: http.MethodPut, header: map[string]string{"If-Match": `W/"v2"`}, expStatusCode: 412},
+		{method: http.MethodPut, header: map[string]string{"If-Match": "*"}, expStatusCode: 200},
+		{method: http.MethodPut, header: map[string]string{"If-Unmodified-Since": before}, expStatusCode: 412},
+		{method: http.MethodPut, header: map[string]string{"If-Unmodified-Since": after}, expStatusCode: 200},
+		{method: http.MethodPut, header: map[string]string{"If-None-Match": "*"}, expStatusCode: 412},
+		{method: http.MethodDelete, header: map[string]string{"If-Match": `"v1", "v2"`}, expStatusCode: 200},
+	}
+
+	for i, tc := range tests {
+		calls = 0
+		r := httptest.NewRequest(tc.method, "/books/1", nil)
+		for k, v := range tc.header {
+			r.Header.Set(k, v)
+		}
+
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("%d: expected status code %d; got %d", i, tc.expStatusCode, rec.Code)
+		}
+
+		if expCalls := map[bool]int{true: 1, false: 0}[rec.Code == http.StatusOK]; calls != expCalls {
+			t.Fatalf("%d: expected %d calls; got %d", i, expCalls, calls)
+		}
+
+		if got := rec.Header().Get("ETag"); got != `"v2"` {
+			t.Fatalf("%d: expected ETag %q; got %q", i, `"v2"`, got)
+		}
+
+		if got := rec.Header().Get("Last-Modified"); got != modified.Format(http.TimeFormat) {
+			t.Fatalf("%d: expected Last-Modified %q; got %q", i, modified.Format(http.TimeFormat), got)
+		}
+	}
+}
+
+func TestWithResolver(t *testing.T) {
+	v := etag.Validator{ETag: etag.Strong("v2")}
+
+	var calls int
+	m := mux.New()
+	m.Use(etag.New())
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = io.WriteString(w, "book")
+	}
+	resolver := etag.WithResolver(func(r *http.Request) (etag.Validator, error) {
+		switch mux.GetVars(r.Context()).Get("id") {
+		case "0":
+			return etag.Validator{}, nil
+		case "err":
+			return etag.Validator{}, mux.NewHTTPError(http.StatusServiceUnavailable, "store down")
+		}
+		return v, nil
+	})
+	m.HandleFunc(http.MethodGet, "/books/:id", handler, resolver)
+	m.HandleFunc(http.MethodPut, "/books/:id", handler, resolver)
+	m.HandleFunc(http.MethodDelete, "/books/:id", handler, resolver)
+
+	tests := []struct {
+		method        string
+		id            string
+		header        map[string]string
+		expStatusCode int
+		expETag       string
+	}{
+		{method: http.MethodPut, id: "1", expStatusCode: 200},
+		{method: http.MethodPut, id: "1", header: map[string]string{"If-Match": `"v2"`}, expStatusCode: 200},
+		{method: http.MethodPut, id: "1", header: map[string]string{"If-Match": `"v1"`}, expStatusCode: 412, expETag: `"v2"`},
+		{method: http.MethodDelete, id: "1", header: map[string]string{"If-None-Match": "*"}, expStatusCode: 412, expETag: `"v2"`},
+		{method: http.MethodPut, id: "0", header: map[string]string{"If-Match": "*"}, expStatusCode: 412},
+		{method: http.MethodPut, id: "0", header: map[string]string{"If-None-Match": "*"}, expStatusCode: 200},
+		{method: http.MethodPut, id: "err", header: map[string]string{"If-Match": `"v2"`}, expStatusCode: 503},
+		{method: http.MethodGet, id: "1", header: map[string]string{"If-None-Match": `"v2"`}, expStatusCode: 304, expETag: `"v2"`},
+	}
+
+	for i, tc := range tests {
+		calls = 0
+		r := httptest.NewRequest(tc.method, "/books/"+tc.id, nil)
+		for k, v := range tc.header {
+			r.Header.Set(k, v)
+		}
+
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("%d: expected status code %d; got %d", i, tc.expStatusCode, rec.Code)
+		}
+
+		if expCalls := map[bool]int{true: 1, false: 0}[rec.Code == http.StatusOK]; calls != expCalls {
+			t.Fatalf("%d: expected %d calls; got %d", i, expCalls, calls)
+		}
+
+		if got := rec.Header().Get("ETag"); got != tc.expETag {
+			t.Fatalf("%d: expected ETag %q; got %q", i, tc.expETag, got)
+		}
+	}
+}
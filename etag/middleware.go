@@ -0,0 +1,221 @@
+package etag
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/websocket"
+)
+
+// OptionApplier is a function for applying option.
+type OptionApplier func(o *Options)
+
+// Options holds the ETag middleware optional fields.
+type Options struct {
+	// Weak makes the derived entity tags weak, e.g. when a later
+	// middleware re-encodes the body.
+	Weak bool
+
+	// MaxSize is the size in bytes of the largest buffered body. Larger
+	// responses are streamed without an entity tag.
+	MaxSize int
+}
+
+// Default is a default option applier.
+func Default() OptionApplier {
+	return func(o *Options) {
+		o.Weak = false
+		o.MaxSize = 1 << 20
+	}
+}
+
+// WithWeak sets the Weak.
+func WithWeak(weak bool) OptionApplier {
+	return func(o *Options) {
+		o.Weak = weak
+	}
+}
+
+// WithMaxSize sets the MaxSize.
+func WithMaxSize(n int) OptionApplier {
+	return func(o *Options) {
+		o.MaxSize = n
+	}
+}
+
+// New creates a new ETag middleware with Default option.
+//
+// Only the 200 OK responses of GET and HEAD requests are tagged. An ETag set
+// by the handler is kept, and a response already answered by Validate is
+// written as it is. The preconditions failing on the tagged response are
+// answered with 304 Not Modified or 412 Precondition Failed. The conditional
+// requests of the routes registered with WithResolver are answered before
+// the handler runs, whatever their method.
+//
+// Flushing streams the response without an entity tag, and a hijacked
+// connection is left to the handler. The WebSocket upgrade requests are
+// passed through.
+func New(appliers ...OptionApplier) mux.MiddlewareFunc {
+	var options Options
+	Default()(&options)
+
+	for _, apply := range appliers {
+		apply(&options)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if websocket.IsUpgrade(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !precondition(w, r) {
+				return
+			}
+
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			bw := &bufferWriter{w: w, maxSize: options.MaxSize}
+			next.ServeHTTP(mux.HookResponseWriter(w, mux.ResponseWriterHooks{
+				WriteHeader: bw.writeHeader,
+				Write:       bw.write,
+				Flush:       bw.flush,
+				Hijack:      bw.hijack,
+			}), r)
+
+			if bw.streaming || bw.hijacked {
+				return
+			}
+
+			if bw.status == 0 {
+				bw.status = http.StatusOK
+			}
+
+			if bw.status != http.StatusOK {
+				_ = bw.writeBuffer()
+				return
+			}
+
+			h := w.Header()
+			v := Validator{ETag: h.Get("ETag")}
+			if v.ETag == "" {
+				v.ETag = hash(bw.buf)
+				if options.Weak {
+					v.ETag = "W/" + v.ETag
+				}
+				h.Set("ETag", v.ETag)
+			}
+
+			if t, err := http.ParseTime(h.Get("Last-Modified")); err == nil {
+				v.LastModified = t
+			}
+
+			switch evaluate(r, v) {
+			case http.StatusNotModified:
+				writeNotModified(w)
+				return
+			case http.StatusPreconditionFailed:
+				mux.Error(w, r, http.StatusPreconditionFailed)
+				return
+			}
+
+			_ = bw.writeBuffer()
+		})
+	}
+}
+
+// bufferWriter buffers the response until it exceeds the maximum size or
+// is flushed, after which it is streamed.
+type bufferWriter struct {
+	w       http.ResponseWriter
+	maxSize int
+
+	status    int
+	buf       []byte
+	streaming bool
+	hijacked  bool
+}
+
+func (bw *bufferWriter) writeHeader(code int) {
+	if bw.streaming || code < http.StatusOK && code != http.StatusSwitchingProtocols {
+		bw.w.WriteHeader(code)
+		return
+	}
+
+	if bw.status == 0 {
+		bw.status = code
+	}
+}
+
+func (bw *bufferWriter) write(b []byte) (int, error) {
+	if bw.streaming {
+		return bw.w.Write(b)
+	}
+
+	if bw.status == 0 {
+		bw.status = http.StatusOK
+	}
+
+	if len(bw.buf)+len(b) > bw.maxSize {
+		if err := bw.stream(); err != nil {
+			return 0, err
+		}
+		return bw.w.Write(b)
+	}
+
+	bw.buf = append(bw.buf, b...)
+	return len(b), nil
+}
+
+func (bw *bufferWriter) flush() {
+	if bw.hijacked {
+		return
+	}
+
+	if !bw.streaming {
+		if err := bw.stream(); err != nil {
+			return
+		}
+	}
+	bw.w.(http.Flusher).Flush()
+}
+
+func (bw *bufferWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := bw.w.(http.Hijacker).Hijack()
+	if err == nil {
+		bw.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// stream writes the buffered response and switches to streaming.
+func (bw *bufferWriter) stream() error {
+	bw.streaming = true
+	return bw.writeBuffer()
+}
+
+// writeBuffer writes the status and the buffered body.
+func (bw *bufferWriter) writeBuffer() error {
+	if bw.status == 0 {
+		bw.status = http.StatusOK
+	}
+
+	// the buffered status may be a 304 written by Validate, which has no
+	// body.
+	bw.w.WriteHeader(bw.status)
+
+	buf := bw.buf
+	bw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	_, err := bw.w.Write(buf)
+	return err
+}
@@ -1,14 +1,16 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"strconv"
 	"sync"
 	"time"
 
 	"github.com/josestg/mux"
+	"github.com/josestg/mux/typed"
 )
 
 func main() {
@@ -21,10 +23,13 @@ func main() {
 		responseJSON(w, http.StatusOK, map[string]string{"msg": "OK"})
 	})
 
-	m.HandleFunc(http.MethodGet, "/books", h.list)
-	m.HandleFunc(http.MethodPost, "/books", h.create)
-	m.HandleFunc(http.MethodGet, "/books/:id", h.detail)
-	m.HandleFunc(http.MethodDelete, "/books/:id", h.delete)
+	typed.Handle(m, http.MethodGet, "/books", h.list)
+	typed.Handle(m, http.MethodPost, "/books", h.create, mux.WithContentTypes("application/json"))
+	typed.Handle(m, http.MethodGet, "/books/:id", h.detail)
+	typed.Handle(m, http.MethodDelete, "/books/:id", h.delete)
+
+	// limit request bodies to 1MB
+	m.Use(mux.RequestBody(1 << 20))
 
 	// add global middleware
 	m.Use(func(next http.Handler) http.Handler {
@@ -59,53 +64,50 @@ func newBookHandler() *bookHandler {
 	}
 }
 
-func (h *bookHandler) list(w http.ResponseWriter, r *http.Request) {
-	books := h.repo.all()
-	responseJSON(w, http.StatusOK, books)
+var errBookNotFound = mux.NewHTTPError(http.StatusNotFound, "book is not found")
+
+type bookID struct {
+	ID int `path:"id"`
 }
 
-func (h *bookHandler) create(w http.ResponseWriter, r *http.Request) {
-	var input struct {
-		Title string `json:"title"`
-	}
+type createBook struct {
+	Title string `json:"title"`
+}
 
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		responseJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
-		return
+func (in createBook) Validate() error {
+	if in.Title == "" {
+		return errors.New("title is required")
 	}
+	return nil
+}
 
+func (h *bookHandler) list(ctx context.Context, _ struct{}) ([]book, error) {
+	return h.repo.all(), nil
+}
+
+func (h *bookHandler) create(ctx context.Context, in createBook) (book, error) {
 	id := len(h.repo.all()) + 1
-	newBook := book{ID: id, Title: input.Title, DateCreated: time.Now()}
+	newBook := book{ID: id, Title: in.Title, DateCreated: time.Now()}
 	h.repo.add(newBook)
-
-	responseJSON(w, http.StatusCreated, newBook)
+	return newBook, nil
 }
 
-func (h *bookHandler) detail(w http.ResponseWriter, r *http.Request) {
-	vars := mux.GetVars(r.Context())
-	id, _ := strconv.Atoi(vars.Get("id"))
-
-	b, ok := h.repo.get(id)
+func (h *bookHandler) detail(ctx context.Context, in bookID) (book, error) {
+	b, ok := h.repo.get(in.ID)
 	if !ok {
-		responseJSON(w, http.StatusNotFound, map[string]string{"error": "Not Found"})
-		return
+		return book{}, errBookNotFound
 	}
-
-	responseJSON(w, http.StatusOK, b)
+	return b, nil
 }
 
-func (h *bookHandler) delete(w http.ResponseWriter, r *http.Request) {
-	vars := mux.GetVars(r.Context())
-	id, _ := strconv.Atoi(vars.Get("id"))
-
-	b, ok := h.repo.get(id)
+func (h *bookHandler) delete(ctx context.Context, in bookID) (book, error) {
+	b, ok := h.repo.get(in.ID)
 	if !ok {
-		responseJSON(w, http.StatusNotFound, map[string]string{"error": "Not Found"})
-		return
+		return book{}, errBookNotFound
 	}
 
-	h.repo.delete(id)
-	responseJSON(w, http.StatusOK, b)
+	h.repo.delete(in.ID)
+	return b, nil
 }
 
 func responseJSON(w http.ResponseWriter, code int, v interface{}) {
@@ -0,0 +1,298 @@
+package mux
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// filepathVar is the name of the wildcard variable of the ServeFiles routes.
+const filepathVar = "filepath"
+
+// FileServerOptions holds the optional fields of ServeFiles.
+type FileServerOptions struct {
+	// Listing enables listing the entries of directories without an
+	// index.html.
+	Listing bool
+
+	// Precompressed enables serving the .br and .gz variants of a file,
+	// when they exist and the client accepts their encoding.
+	Precompressed bool
+
+	// RouteOptions are applied to the registered routes.
+	RouteOptions []RouteOptionApplier
+}
+
+// FileServerOptionApplier is a function for applying file server option.
+type FileServerOptionApplier func(o *FileServerOptions)
+
+// WithListing is a file server option applier enabling directory listing.
+func WithListing(enabled bool) FileServerOptionApplier {
+	return func(o *FileServerOptions) {
+		o.Listing = enabled
+	}
+}
+
+// WithPrecompressed is a file server option applier enabling the .br and
+// .gz variants. It is enabled by default.
+func WithPrecompressed(enabled bool) FileServerOptionApplier {
+	return func(o *FileServerOptions) {
+		o.Precompressed = enabled
+	}
+}
+
+// WithFileRouteOptions is a file server option applier for the options of
+// the registered routes.
+func WithFileRouteOptions(appliers ...RouteOptionApplier) FileServerOptionApplier {
+	return func(o *FileServerOptions) {
+		o.RouteOptions = append(o.RouteOptions, appliers...)
+	}
+}
+
+// ServeFiles registers GET and HEAD routes serving the files of fsys under
+// the prefix, e.g. ServeFiles("/assets", fsys) serves /assets/css/app.css
+// from css/app.css. The fsys can be an embed.FS or a directory opened with
+// os.DirFS.
+//
+// Responses carry an ETag and, when the file has a modification time, a
+// Last-Modified header, and conditional and range requests are handled.
+// Directories are served by their index.html. Paths escaping fsys are not
+// found.
+func (m *Mux) ServeFiles(prefix string, fsys fs.FS, appliers ...FileServerOptionApplier) {
+	opts := &FileServerOptions{Precompressed: true}
+	for _, apply := range appliers {
+		apply(opts)
+	}
+
+	fsrv := &fileServer{fsys: fsys, opts: opts}
+	pattern := strings.TrimSuffix(prefix, "/") + "/*" + filepathVar
+
+	m.Handle(http.MethodGet, pattern, fsrv, opts.RouteOptions...)
+	m.Handle(http.MethodHead, pattern, fsrv, opts.RouteOptions...)
+}
+
+type fileServer struct {
+	fsys fs.FS
+	opts *FileServerOptions
+
+	// etags caches the ETags computed from the content of the files
+	// without a modification time, such as the files of an embed.FS.
+	etags sync.Map
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := fileName(GetVars(r.Context()).Get(filepathVar))
+	if !ok {
+		Error(w, r, http.StatusNotFound)
+		return
+	}
+
+	f, info, err := s.open(name)
+	if err != nil {
+		s.error(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	if info.IsDir() {
+		s.serveDir(w, r, f, name)
+		return
+	}
+
+	s.serveFile(w, r, f, info, name)
+}
+
+// fileName converts the wildcard value to a name of the fs.FS.
+func fileName(p string) (string, bool) {
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		name = "."
+	}
+
+	if strings.Contains(name, `\`) || !fs.ValidPath(name) {
+		return "", false
+	}
+	return name, true
+}
+
+func (s *fileServer) open(name string) (fs.File, fs.FileInfo, error) {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+
+	return f, info, nil
+}
+
+func (s *fileServer) error(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		Error(w, r, http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		Error(w, r, http.StatusForbidden)
+	default:
+		Error(w, r, http.StatusInternalServerError)
+	}
+}
+
+func (s *fileServer) serveDir(w http.ResponseWriter, r *http.Request, dir fs.File, name string) {
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		localRedirect(w, r, path.Base(r.URL.Path)+"/")
+		return
+	}
+
+	index := path.Join(name, "index.html")
+	if f, info, err := s.open(index); err == nil {
+		defer f.Close()
+		if !info.IsDir() {
+			s.serveFile(w, r, f, info, index)
+			return
+		}
+	}
+
+	if !s.opts.Listing {
+		Error(w, r, http.StatusNotFound)
+		return
+	}
+
+	rd, ok := dir.(fs.ReadDirFile)
+	if !ok {
+		Error(w, r, http.StatusNotFound)
+		return
+	}
+
+	entries, err := rd.ReadDir(-1)
+	if err != nil {
+		s.error(w, r, err)
+		return
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var buf bytes.Buffer
+	buf.WriteString("<!doctype html>\n<pre>\n")
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() {
+			n += "/"
+		}
+		u := url.URL{Path: n}
+		fmt.Fprintf(&buf, "<a href=\"%s\">%s</a>\n", html.EscapeString(u.String()), html.EscapeString(n))
+	}
+	buf.WriteString("</pre>\n")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// encodings are the precompressed variants by preference.
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{name: "br", ext: ".br"},
+	{name: "gzip", ext: ".gz"},
+}
+
+func (s *fileServer) serveFile(w http.ResponseWriter, r *http.Request, f fs.File, info fs.FileInfo, name string) {
+	if s.opts.Precompressed {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		accept := r.Header.Get("Accept-Encoding")
+		for _, enc := range encodings {
+			if encodingQuality(accept, enc.name) == 0 {
+				continue
+			}
+
+			cf, cinfo, err := s.open(name + enc.ext)
+			if err != nil {
+				continue
+			}
+			defer cf.Close()
+
+			if cinfo.IsDir() {
+				continue
+			}
+
+			w.Header().Set("Content-Encoding", enc.name)
+			s.serveContent(w, r, cf, cinfo, name+enc.ext, name)
+			return
+		}
+	}
+
+	s.serveContent(w, r, f, info, name, name)
+}
+
+// serveContent serves f with the content type derived from typeName.
+func (s *fileServer) serveContent(w http.ResponseWriter, r *http.Request, f fs.File, info fs.FileInfo, name, typeName string) {
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			s.error(w, r, err)
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+
+	etag, err := s.etag(content, info, name)
+	if err != nil {
+		s.error(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", etag)
+
+	http.ServeContent(w, r, typeName, info.ModTime(), content)
+}
+
+// etag derives the ETag from the size and modification time of the file,
+// or from its content when it has no modification time.
+func (s *fileServer) etag(content io.ReadSeeker, info fs.FileInfo, name string) (string, error) {
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()), nil
+	}
+
+	if etag, ok := s.etags.Load(name); ok {
+		return etag.(string), nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := fmt.Sprintf(`"%x"`, h.Sum(nil)[:16])
+	s.etags.Store(name, etag)
+	return etag, nil
+}
+
+// localRedirect redirects to the target relative to the request path, like
+// net/http does, so a path such as //example.com can not turn into a
+// redirect to another host.
+func localRedirect(w http.ResponseWriter, r *http.Request, target string) {
+	if q := r.URL.RawQuery; q != "" {
+		target += "?" + q
+	}
+	w.Header().Set("Location", target)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
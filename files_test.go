@@ -0,0 +1,251 @@
+package mux_test
+
+import (
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/josestg/mux"
+)
+
+func TestMux_ServeFiles(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"app.js":             {Data: []byte("console.log(1)"), ModTime: modTime},
+		"app.js.gz":          {Data: []byte("gzip"), ModTime: modTime},
+		"app.js.br":          {Data: []byte("brotli"), ModTime: modTime},
+		"css/app.css":        {Data: []byte("body{}")},
+		"docs/index.html":    {Data: []byte("<h1>docs</h1>")},
+		"images/logo.svg":    {Data: []byte("<svg/>")},
+		"images/a&b.png":     {Data: []byte("png")},
+		"images/nested/x.js": {Data: []byte("x")},
+	}
+
+	handler := mux.New()
+	handler.ServeFiles("/assets/", fsys)
+	handler.Handle(http.MethodGet, "/assets/version", fakeHandler(0))
+
+	tests := []struct {
+		method          string
+		path            string
+		header          map[string]string
+		expStatusCode   int
+		expBody         string
+		expContentType  string
+		expEncoding     string
+		expLastModified string
+		expLocation     string
+	}{
+		{
+			method:          http.MethodGet,
+			path:            "/assets/app.js",
+			expStatusCode:   200,
+			expBody:         "console.log(1)",
+			expContentType:  mime.TypeByExtension(".js"),
+			expLastModified: "Tue, 02 Jan 2024 03:04:05 GMT",
+		},
+		{
+			method:         http.MethodGet,
+			path:           "/assets/app.js",
+			header:         map[string]string{"Accept-Encoding": "gzip, br"},
+			expStatusCode:  200,
+			expBody:        "brotli",
+			expContentType: mime.TypeByExtension(".js"),
+			expEncoding:    "br",
+		},
+		{
+			method:         http.MethodGet,
+			path:           "/assets/app.js",
+			header:         map[string]string{"Accept-Encoding": "gzip, br;q=0"},
+			expStatusCode:  200,
+			expBody:        "gzip",
+			expContentType: mime.TypeByExtension(".js"),
+			expEncoding:    "gzip",
+		},
+		{
+			method:         http.MethodHead,
+			path:           "/assets/css/app.css",
+			expStatusCode:  200,
+			expContentType: mime.TypeByExtension(".css"),
+		},
+		{
+			method:         http.MethodGet,
+			path:           "/assets/docs/",
+			expStatusCode:  200,
+			expBody:        "<h1>docs</h1>",
+			expContentType: "text/html; charset=utf-8",
+		},
+		{
+			method:        http.MethodGet,
+			path:          "/assets/docs",
+			expStatusCode: 301,
+			expLocation:   "docs/",
+		},
+		{
+			method:        http.MethodGet,
+			path:          "/assets/docs?lang=en",
+			expStatusCode: 301,
+			expLocation:   "docs/?lang=en",
+		},
+		{
+			method:        http.MethodGet,
+			path:          "/assets/images/",
+			expStatusCode: 404,
+		},
+		{
+			method:        http.MethodGet,
+			path:          "/assets/missing.js",
+			expStatusCode: 404,
+		},
+		{
+			method:        http.MethodGet,
+			path:          "/assets/../../etc/passwd",
+			expStatusCode: 404,
+		},
+		{
+			method:        http.MethodGet,
+			path:          "/assets/%2e%2e/%2e%2e/etc/passwd",
+			expStatusCode: 404,
+		},
+		{
+			method:        http.MethodGet,
+			path:          "/assets/version",
+			expStatusCode: 200,
+			expBody:       `{"id":0,"method":"GET","path":"/assets/version","vars":{}}` + "\n",
+		},
+		{
+			method:        http.MethodPost,
+			path:          "/assets/app.js",
+			expStatusCode: 405,
+			expBody:       "Method Not Allowed\n",
+		},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		for k, v := range tc.header {
+			r.Header.Set(k, v)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("%d: expected status code %d; got %d", i, tc.expStatusCode, rec.Code)
+		}
+
+		if tc.expBody != "" && rec.Body.String() != tc.expBody {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.expBody, rec.Body.String())
+		}
+
+		if tc.expContentType != "" && rec.Header().Get("Content-Type") != tc.expContentType {
+			t.Fatalf("%d: expected content type %q; got %q", i, tc.expContentType, rec.Header().Get("Content-Type"))
+		}
+
+		if got := rec.Header().Get("Content-Encoding"); got != tc.expEncoding {
+			t.Fatalf("%d: expected content encoding %q; got %q", i, tc.expEncoding, got)
+		}
+
+		if tc.expLastModified != "" && rec.Header().Get("Last-Modified") != tc.expLastModified {
+			t.Fatalf("%d: expected last modified %q; got %q", i, tc.expLastModified, rec.Header().Get("Last-Modified"))
+		}
+
+		if got := rec.Header().Get("Location"); got != tc.expLocation {
+			t.Fatalf("%d: expected location %q; got %q", i, tc.expLocation, got)
+		}
+
+		if tc.expContentType != "" && rec.Code == http.StatusOK && rec.Header().Get("ETag") == "" {
+			t.Fatalf("%d: expected ETag", i)
+		}
+	}
+}
+
+func TestMux_ServeFiles_OpenRedirect(t *testing.T) {
+	fsys := fstest.MapFS{
+		"evil.com/index.html": {Data: []byte("<h1>evil</h1>")},
+	}
+
+	handler := mux.New()
+	handler.ServeFiles("/", fsys)
+
+	for i, p := range []string{"//evil.com", "/evil.com", "///evil.com"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, p, nil))
+
+		if rec.Code != http.StatusMovedPermanently {
+			t.Fatalf("%d: expected status code %d; got %d", i, http.StatusMovedPermanently, rec.Code)
+		}
+
+		if got := rec.Header().Get("Location"); got != "evil.com/" {
+			t.Fatalf("%d: expected relative location %q; got %q", i, "evil.com/", got)
+		}
+	}
+}
+
+func TestMux_ServeFiles_Conditional(t *testing.T) {
+	fsys := fstest.MapFS{"app.css": {Data: []byte("body{}")}}
+
+	handler := mux.New()
+	handler.ServeFiles("/", fsys)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/app.css", nil))
+
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("expected ETag")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/app.css", nil)
+	r.Header.Set("If-None-Match", etag)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected status code %d; got %d", http.StatusNotModified, rec.Code)
+	}
+}
+
+func TestMux_ServeFiles_Listing(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"b.txt", "a<1>.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handler := mux.New()
+	handler.ServeFiles("/files", os.DirFS(dir), mux.WithListing(true))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status code %d; got %d", http.StatusOK, rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, exp := range []string{`<a href="a%3C1%3E.txt">a&lt;1&gt;.txt</a>`, `<a href="b.txt">b.txt</a>`, `<a href="sub/">sub/</a>`} {
+		if !strings.Contains(body, exp) {
+			t.Fatalf("expected body containing %q; got %q", exp, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/b.txt", nil))
+
+	if rec.Body.String() != "b.txt" || rec.Header().Get("Last-Modified") == "" {
+		t.Fatalf("expected file with last modified; got %q %v", rec.Body.String(), rec.Header())
+	}
+}
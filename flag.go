@@ -0,0 +1,89 @@
+package mux
+
+import (
+	"context"
+	"strings"
+
+	"github.com/josestg/mux/internal/trie"
+)
+
+// FlagProvider reports whether the feature flag is on for the request with
+// the given context. It is called while routing the request, before the
+// middlewares run, so the context is the one of the incoming request.
+type FlagProvider func(ctx context.Context, flag string) bool
+
+type flagKey struct{}
+
+type flagGate struct {
+	provider FlagProvider
+	flag     string
+}
+
+// WithFlag is a route option applier gating the Route behind the feature
+// flag. While the flag is off, the Route behaves as if it was not
+// registered: the request is served by a route registered after it for the
+// same method and pattern, by the MethodNotFoundHandler when other methods
+// are registered for the path, or by the RoutesNotFoundHandler.
+//
+// Unlike the other routes, a method and pattern can be registered again
+// after a gated Route, e.g. to keep the current handler while the flag of
+// its replacement is off:
+//
+//	m.Handle(http.MethodGet, "/books", booksV2, mux.WithFlag(flags, "books-v2"))
+//	m.Handle(http.MethodGet, "/books", books)
+func WithFlag(provider FlagProvider, flag string) RouteOptionApplier {
+	return WithValue(flagKey{}, flagGate{provider: provider, flag: flag})
+}
+
+// gated reports whether the route is gated by a feature flag.
+func (rt *route) gated() bool {
+	_, ok := rt.Value(flagKey{}).(flagGate)
+	return ok
+}
+
+// resolve returns the first route of the chain whose flag is on, nil when
+// all are off.
+func (rt *route) resolve(ctx context.Context) *route {
+	for ; rt != nil; rt = rt.next {
+		g, ok := rt.Value(flagKey{}).(flagGate)
+		if !ok || g.provider(ctx, g.flag) {
+			return rt
+		}
+	}
+	return nil
+}
+
+// search is like the search of the router, treating the routes whose
+// flags are off as not registered.
+func (t *routeTable) search(ctx context.Context, method string, path string) (trie.Result, error) {
+	res, err := t.router.Search(method, path)
+	if !t.flagged || (err != nil && err != trie.ErrMethodNotFound) {
+		return res, err
+	}
+
+	if err == nil {
+		if rt := res.Handler.(*route).resolve(ctx); rt != nil {
+			res.Handler = rt
+			return res, nil
+		}
+		res.Handler = nil
+	}
+
+	methods := make([]string, 0, len(res.Methods))
+	for _, other := range res.Methods {
+		if strings.EqualFold(other, method) {
+			continue
+		}
+
+		if h, ok := res.HandlerOf(other); ok && h.(*route).resolve(ctx) != nil {
+			methods = append(methods, other)
+		}
+	}
+
+	if len(methods) == 0 {
+		return trie.Result{Vars: res.Vars}, trie.ErrPathNotFound
+	}
+
+	res.Methods = methods
+	return res, trie.ErrMethodNotFound
+}
@@ -0,0 +1,110 @@
+package mux_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/josestg/mux"
+)
+
+type betaKey struct{}
+
+type flags struct {
+	mu sync.Mutex
+	on map[string]bool
+}
+
+func (f *flags) set(flag string, on bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.on[flag] = on
+}
+
+// enabled turns the flags on for everyone, and the beta flag for the beta
+// testers only.
+func (f *flags) enabled(ctx context.Context, flag string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if flag == "beta" {
+		beta, _ := ctx.Value(betaKey{}).(bool)
+		return beta
+	}
+	return f.on[flag]
+}
+
+func TestWithFlag(t *testing.T) {
+	f := &flags{on: make(map[string]bool)}
+
+	handler := mux.New()
+	handler.Handle(http.MethodGet, "/books", named("books v2"), mux.WithFlag(f.enabled, "books-v2"))
+	handler.Handle(http.MethodGet, "/books", named("books v3"), mux.WithFlag(f.enabled, "books-v3"))
+	handler.Handle(http.MethodGet, "/books", named("books v1"))
+	handler.Handle(http.MethodGet, "/reports", named("reports"), mux.WithFlag(f.enabled, "reports"))
+	handler.Handle(http.MethodPost, "/reports", named("create report"))
+	handler.Handle(http.MethodGet, "/labs/:id", named("labs"), mux.WithFlag(f.enabled, "beta"))
+
+	tests := []struct {
+		flag      string
+		on        bool
+		method    string
+		path      string
+		beta      bool
+		expStatus int
+		expBody   string
+		expAllow  string
+	}{
+		{method: http.MethodGet, path: "/books", expStatus: http.StatusOK, expBody: "books v1"},
+		{flag: "books-v3", on: true, method: http.MethodGet, path: "/books", expStatus: http.StatusOK, expBody: "books v3"},
+		{flag: "books-v2", on: true, method: http.MethodGet, path: "/books", expStatus: http.StatusOK, expBody: "books v2"},
+		{method: http.MethodGet, path: "/reports", expStatus: http.StatusMethodNotAllowed, expAllow: "POST"},
+		{method: http.MethodPut, path: "/reports", expStatus: http.StatusMethodNotAllowed, expAllow: "POST"},
+		{flag: "reports", on: true, method: http.MethodGet, path: "/reports", expStatus: http.StatusOK, expBody: "reports"},
+		{method: http.MethodPut, path: "/reports", expStatus: http.StatusMethodNotAllowed, expAllow: "GET, POST"},
+		{method: http.MethodGet, path: "/labs/1", expStatus: http.StatusNotFound},
+		{method: http.MethodPost, path: "/labs/1", expStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/labs/1", beta: true, expStatus: http.StatusOK, expBody: "labs"},
+	}
+
+	for i, tc := range tests {
+		if tc.flag != "" {
+			f.set(tc.flag, tc.on)
+		}
+
+		// the flags are evaluated before the middlewares run, so the
+		// context is set on the incoming request.
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		r = r.WithContext(context.WithValue(r.Context(), betaKey{}, tc.beta))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatus {
+			t.Fatalf("%d: expected status code %d; got %d", i, tc.expStatus, rec.Code)
+		}
+
+		if tc.expBody != "" && rec.Body.String() != tc.expBody {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.expBody, rec.Body.String())
+		}
+
+		if got := rec.Header().Get("Allow"); got != tc.expAllow {
+			t.Fatalf("%d: expected Allow %q; got %q", i, tc.expAllow, got)
+		}
+	}
+
+	f.set("reports", false)
+	if _, err := handler.Match(http.MethodGet, "/reports"); !errors.Is(err, mux.ErrMethodNotAllowed) {
+		t.Fatalf("expected error %v; got %v", mux.ErrMethodNotAllowed, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic when registering after a route without flag")
+		}
+	}()
+	handler.Handle(http.MethodGet, "/books", named("books v4"))
+}
@@ -0,0 +1,104 @@
+package codec
+
+import (
+	"bytes"
+	"math"
+	"time"
+)
+
+// MarshalCBOR returns the CBOR encoding of v. Integers and lengths use
+// their shortest form, and times are encoded as tag 0 RFC 3339 strings.
+//
+// See: https://www.rfc-editor.org/rfc/rfc8949.
+func MarshalCBOR(v any) ([]byte, error) {
+	return marshal(cbor{}, "cbor", v)
+}
+
+// CBOR major types.
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborBytes  = 2 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborTag    = 6 << 5
+)
+
+type cbor struct{}
+
+// writeHead writes the initial byte of the major type with its argument.
+func writeHead(b *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		b.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		b.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		b.WriteByte(major | 25)
+		writeUint16(b, uint16(n))
+	case n <= math.MaxUint32:
+		b.WriteByte(major | 26)
+		writeUint32(b, uint32(n))
+	default:
+		b.WriteByte(major | 27)
+		writeUint64(b, n)
+	}
+}
+
+func (cbor) writeNil(b *bytes.Buffer) {
+	b.WriteByte(0xf6)
+}
+
+func (cbor) writeBool(b *bytes.Buffer, v bool) {
+	if v {
+		b.WriteByte(0xf5)
+	} else {
+		b.WriteByte(0xf4)
+	}
+}
+
+func (cbor) writeInt(b *bytes.Buffer, v int64) {
+	if v >= 0 {
+		writeHead(b, cborUint, uint64(v))
+		return
+	}
+	writeHead(b, cborNegInt, uint64(-(v + 1)))
+}
+
+func (cbor) writeUint(b *bytes.Buffer, v uint64) {
+	writeHead(b, cborUint, v)
+}
+
+func (cbor) writeFloat32(b *bytes.Buffer, v float32) {
+	b.WriteByte(0xfa)
+	writeUint32(b, math.Float32bits(v))
+}
+
+func (cbor) writeFloat64(b *bytes.Buffer, v float64) {
+	b.WriteByte(0xfb)
+	writeUint64(b, math.Float64bits(v))
+}
+
+func (cbor) writeString(b *bytes.Buffer, v string) {
+	writeHead(b, cborText, uint64(len(v)))
+	b.WriteString(v)
+}
+
+func (cbor) writeBytes(b *bytes.Buffer, v []byte) {
+	writeHead(b, cborBytes, uint64(len(v)))
+	b.Write(v)
+}
+
+func (cbor) writeArrayHeader(b *bytes.Buffer, n int) {
+	writeHead(b, cborArray, uint64(n))
+}
+
+func (cbor) writeMapHeader(b *bytes.Buffer, n int) {
+	writeHead(b, cborMap, uint64(n))
+}
+
+func (c cbor) writeTime(b *bytes.Buffer, t time.Time) {
+	writeHead(b, cborTag, 0)
+	c.writeString(b, t.Format(time.RFC3339Nano))
+}
@@ -0,0 +1,293 @@
+// Package codec implements the MessagePack and CBOR encoders used by the
+// Mux response encoders.
+//
+// Both formats share the same data model, so values are walked once by
+// reflection and emitted through a format specific emitter. Struct fields
+// are named by the format tag (msgpack or cbor), then the json tag, then
+// the field name, and support the "-" and "omitempty" options.
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// emitter writes the primitives of a format.
+type emitter interface {
+	writeNil(b *bytes.Buffer)
+	writeBool(b *bytes.Buffer, v bool)
+	writeInt(b *bytes.Buffer, v int64)
+	writeUint(b *bytes.Buffer, v uint64)
+	writeFloat32(b *bytes.Buffer, v float32)
+	writeFloat64(b *bytes.Buffer, v float64)
+	writeString(b *bytes.Buffer, v string)
+	writeBytes(b *bytes.Buffer, v []byte)
+	writeArrayHeader(b *bytes.Buffer, n int)
+	writeMapHeader(b *bytes.Buffer, n int)
+	writeTime(b *bytes.Buffer, t time.Time)
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type encoder struct {
+	emitter
+	tag string
+	buf bytes.Buffer
+}
+
+func marshal(e emitter, tag string, v any) ([]byte, error) {
+	enc := &encoder{emitter: e, tag: tag}
+	if err := enc.encode(&enc.buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return enc.buf.Bytes(), nil
+}
+
+func (e *encoder) encode(b *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		e.writeNil(b)
+		return nil
+	}
+
+	if v.Type() == timeType {
+		e.writeTime(b, v.Interface().(time.Time))
+		return nil
+	}
+
+	if v.Type().Implements(textMarshalerType) && (v.Kind() != reflect.Ptr || !v.IsNil()) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		e.writeString(b, string(text))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.writeNil(b)
+			return nil
+		}
+		return e.encode(b, v.Elem())
+	case reflect.Bool:
+		e.writeBool(b, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.writeInt(b, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.writeUint(b, v.Uint())
+	case reflect.Float32:
+		e.writeFloat32(b, float32(v.Float()))
+	case reflect.Float64:
+		e.writeFloat64(b, v.Float())
+	case reflect.String:
+		e.writeString(b, v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.writeNil(b)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.writeBytes(b, v.Bytes())
+			return nil
+		}
+		return e.encodeArray(b, v)
+	case reflect.Array:
+		return e.encodeArray(b, v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.writeNil(b)
+			return nil
+		}
+		return e.encodeMap(b, v)
+	case reflect.Struct:
+		return e.encodeStruct(b, v)
+	default:
+		return fmt.Errorf("codec: unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func (e *encoder) encodeArray(b *bytes.Buffer, v reflect.Value) error {
+	e.writeArrayHeader(b, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(b, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMap writes the entries sorted by their encoded keys, so the output
+// is deterministic.
+func (e *encoder) encodeMap(b *bytes.Buffer, v reflect.Value) error {
+	type entry struct {
+		key   []byte
+		value reflect.Value
+	}
+
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		var kb bytes.Buffer
+		if err := e.encode(&kb, iter.Key()); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: kb.Bytes(), value: iter.Value()})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+
+	e.writeMapHeader(b, len(entries))
+	for _, en := range entries {
+		b.Write(en.key)
+		if err := e.encode(b, en.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) encodeStruct(b *bytes.Buffer, v reflect.Value) error {
+	fields := cachedFields(v.Type(), e.tag)
+
+	values := make([]reflect.Value, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && isEmpty(fv)) {
+			continue
+		}
+		values = append(values, fv)
+		names = append(names, f.name)
+	}
+
+	e.writeMapHeader(b, len(values))
+	for i, fv := range values {
+		e.writeString(b, names[i])
+		if err := e.encode(b, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but reports false instead
+// of panicking on a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0 && !math.Signbit(v.Float())
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+type fieldsKey struct {
+	t   reflect.Type
+	tag string
+}
+
+var fieldsCache sync.Map
+
+func cachedFields(t reflect.Type, tag string) []field {
+	key := fieldsKey{t: t, tag: tag}
+	if f, ok := fieldsCache.Load(key); ok {
+		return f.([]field)
+	}
+
+	f, _ := fieldsCache.LoadOrStore(key, typeFields(t, tag, nil))
+	return f.([]field)
+}
+
+func typeFields(t reflect.Type, tag string, parent []int) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		index := make([]int, len(parent)+1)
+		copy(index, parent)
+		index[len(parent)] = i
+
+		name, opts := lookupTag(sf, tag)
+		if name == "-" {
+			continue
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, typeFields(ft, tag, index)...)
+			continue
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		fields = append(fields, field{
+			name:      name,
+			index:     index,
+			omitEmpty: strings.Contains(opts, "omitempty"),
+		})
+	}
+	return fields
+}
+
+func lookupTag(sf reflect.StructField, tag string) (string, string) {
+	v, ok := sf.Tag.Lookup(tag)
+	if !ok {
+		v = sf.Tag.Get("json")
+	}
+
+	name, opts, _ := strings.Cut(v, ",")
+	return name, opts
+}
@@ -0,0 +1,115 @@
+package codec
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+type embedded struct {
+	Inner string `json:"inner"`
+}
+
+type sample struct {
+	embedded
+	Name    string `msgpack:"n" cbor:"n"`
+	Skipped string `json:"-"`
+	Empty   int    `json:"empty,omitempty"`
+	hidden  int
+}
+
+func TestMarshalMsgPack(t *testing.T) {
+	tests := []struct {
+		v   any
+		exp string
+	}{
+		{v: nil, exp: "c0"},
+		{v: true, exp: "c3"},
+		{v: false, exp: "c2"},
+		{v: 0, exp: "00"},
+		{v: 127, exp: "7f"},
+		{v: 128, exp: "cc80"},
+		{v: 256, exp: "cd0100"},
+		{v: 1 << 16, exp: "ce00010000"},
+		{v: uint64(1 << 32), exp: "cf0000000100000000"},
+		{v: -1, exp: "ff"},
+		{v: -32, exp: "e0"},
+		{v: -33, exp: "d0df"},
+		{v: -129, exp: "d1ff7f"},
+		{v: 1.5, exp: "cb3ff8000000000000"},
+		{v: float32(1.5), exp: "ca3fc00000"},
+		{v: "a", exp: "a161"},
+		{v: []byte{1, 2}, exp: "c4020102"},
+		{v: []int{1, 2}, exp: "920102"},
+		{v: map[string]int{"b": 2, "a": 1}, exp: "82a16101a16202"},
+		{v: time.Unix(1, 2), exp: "c70cff000000020000000000000001"},
+		{
+			v:   sample{embedded: embedded{Inner: "x"}, Name: "y", Skipped: "z", hidden: 1},
+			exp: "82a5696e6e6572a178a16ea179",
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := MarshalMsgPack(tc.v)
+		if err != nil {
+			t.Fatalf("%#v: unexpected error %v", tc.v, err)
+		}
+
+		if hex.EncodeToString(got) != tc.exp {
+			t.Errorf("%#v: expected %s; got %x", tc.v, tc.exp, got)
+		}
+	}
+}
+
+// The expected values are taken from RFC 8949 Appendix A.
+func TestMarshalCBOR(t *testing.T) {
+	tests := []struct {
+		v   any
+		exp string
+	}{
+		{v: 0, exp: "00"},
+		{v: 23, exp: "17"},
+		{v: 24, exp: "1818"},
+		{v: 100, exp: "1864"},
+		{v: 1000, exp: "1903e8"},
+		{v: 1000000, exp: "1a000f4240"},
+		{v: 1000000000000, exp: "1b000000e8d4a51000"},
+		{v: -1, exp: "20"},
+		{v: -10, exp: "29"},
+		{v: -100, exp: "3863"},
+		{v: -1000, exp: "3903e7"},
+		{v: 1.1, exp: "fb3ff199999999999a"},
+		{v: false, exp: "f4"},
+		{v: true, exp: "f5"},
+		{v: nil, exp: "f6"},
+		{v: "", exp: "60"},
+		{v: "IETF", exp: "6449455446"},
+		{v: []byte{1, 2, 3, 4}, exp: "4401020304"},
+		{v: []int{}, exp: "80"},
+		{v: []int{1, 2, 3}, exp: "83010203"},
+		{v: map[string]any{}, exp: "a0"},
+		{v: map[string]any{"a": 1, "b": []int{2, 3}}, exp: "a26161016162820203"},
+		{v: time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC), exp: "c074323031332d30332d32315432303a30343a30305a"},
+		{
+			v:   sample{embedded: embedded{Inner: "x"}, Name: "y"},
+			exp: "a265696e6e65726178616e6179",
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := MarshalCBOR(tc.v)
+		if err != nil {
+			t.Fatalf("%#v: unexpected error %v", tc.v, err)
+		}
+
+		if hex.EncodeToString(got) != tc.exp {
+			t.Errorf("%#v: expected %s; got %x", tc.v, tc.exp, got)
+		}
+	}
+}
+
+func TestMarshal_Unsupported(t *testing.T) {
+	if _, err := MarshalCBOR(make(chan int)); err == nil {
+		t.Fatalf("expected error for unsupported type")
+	}
+}
@@ -0,0 +1,160 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// MarshalMsgPack returns the MessagePack encoding of v.
+//
+// See: https://github.com/msgpack/msgpack/blob/master/spec.md.
+func MarshalMsgPack(v any) ([]byte, error) {
+	return marshal(msgpack{}, "msgpack", v)
+}
+
+type msgpack struct{}
+
+func (msgpack) writeNil(b *bytes.Buffer) {
+	b.WriteByte(0xc0)
+}
+
+func (msgpack) writeBool(b *bytes.Buffer, v bool) {
+	if v {
+		b.WriteByte(0xc3)
+	} else {
+		b.WriteByte(0xc2)
+	}
+}
+
+func (m msgpack) writeInt(b *bytes.Buffer, v int64) {
+	switch {
+	case v >= 0:
+		m.writeUint(b, uint64(v))
+	case v >= -32:
+		b.WriteByte(byte(v))
+	case v >= math.MinInt8:
+		b.Write([]byte{0xd0, byte(v)})
+	case v >= math.MinInt16:
+		b.WriteByte(0xd1)
+		writeUint16(b, uint16(v))
+	case v >= math.MinInt32:
+		b.WriteByte(0xd2)
+		writeUint32(b, uint32(v))
+	default:
+		b.WriteByte(0xd3)
+		writeUint64(b, uint64(v))
+	}
+}
+
+func (msgpack) writeUint(b *bytes.Buffer, v uint64) {
+	switch {
+	case v <= math.MaxInt8:
+		b.WriteByte(byte(v))
+	case v <= math.MaxUint8:
+		b.Write([]byte{0xcc, byte(v)})
+	case v <= math.MaxUint16:
+		b.WriteByte(0xcd)
+		writeUint16(b, uint16(v))
+	case v <= math.MaxUint32:
+		b.WriteByte(0xce)
+		writeUint32(b, uint32(v))
+	default:
+		b.WriteByte(0xcf)
+		writeUint64(b, v)
+	}
+}
+
+func (msgpack) writeFloat32(b *bytes.Buffer, v float32) {
+	b.WriteByte(0xca)
+	writeUint32(b, math.Float32bits(v))
+}
+
+func (msgpack) writeFloat64(b *bytes.Buffer, v float64) {
+	b.WriteByte(0xcb)
+	writeUint64(b, math.Float64bits(v))
+}
+
+func (msgpack) writeString(b *bytes.Buffer, v string) {
+	n := len(v)
+	switch {
+	case n < 32:
+		b.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		b.Write([]byte{0xd9, byte(n)})
+	case n <= math.MaxUint16:
+		b.WriteByte(0xda)
+		writeUint16(b, uint16(n))
+	default:
+		b.WriteByte(0xdb)
+		writeUint32(b, uint32(n))
+	}
+	b.WriteString(v)
+}
+
+func (msgpack) writeBytes(b *bytes.Buffer, v []byte) {
+	n := len(v)
+	switch {
+	case n <= math.MaxUint8:
+		b.Write([]byte{0xc4, byte(n)})
+	case n <= math.MaxUint16:
+		b.WriteByte(0xc5)
+		writeUint16(b, uint16(n))
+	default:
+		b.WriteByte(0xc6)
+		writeUint32(b, uint32(n))
+	}
+	b.Write(v)
+}
+
+func (msgpack) writeArrayHeader(b *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		b.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(0xdc)
+		writeUint16(b, uint16(n))
+	default:
+		b.WriteByte(0xdd)
+		writeUint32(b, uint32(n))
+	}
+}
+
+func (msgpack) writeMapHeader(b *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		b.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(0xde)
+		writeUint16(b, uint16(n))
+	default:
+		b.WriteByte(0xdf)
+		writeUint32(b, uint32(n))
+	}
+}
+
+// writeTime writes the timestamp extension type -1 in its 96-bit format.
+func (msgpack) writeTime(b *bytes.Buffer, t time.Time) {
+	b.Write([]byte{0xc7, 12, 0xff})
+	writeUint32(b, uint32(t.Nanosecond()))
+	writeUint64(b, uint64(t.Unix()))
+}
+
+func writeUint16(b *bytes.Buffer, v uint16) {
+	var p [2]byte
+	binary.BigEndian.PutUint16(p[:], v)
+	b.Write(p[:])
+}
+
+func writeUint32(b *bytes.Buffer, v uint32) {
+	var p [4]byte
+	binary.BigEndian.PutUint32(p[:], v)
+	b.Write(p[:])
+}
+
+func writeUint64(b *bytes.Buffer, v uint64) {
+	var p [8]byte
+	binary.BigEndian.PutUint64(p[:], v)
+	b.Write(p[:])
+}
@@ -8,6 +8,7 @@ import (
 const (
 	route = iota
 	variable
+	wildcard
 )
 
 type token struct {
@@ -55,7 +56,12 @@ func tokenizePath(segments []string) []token {
 	tokens := make([]token, 0, len(segments))
 
 	for _, v := range segments {
-		if strings.HasPrefix(v, "/:") {
+		if strings.HasPrefix(v, "/*") {
+			tokens = append(tokens, token{
+				kind:  wildcard,
+				value: strings.TrimPrefix(v, "/*"),
+			})
+		} else if strings.HasPrefix(v, "/:") {
 			tokens = append(tokens, token{
 				kind:  variable,
 				value: strings.TrimPrefix(v, "/:"),
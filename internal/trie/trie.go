@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 )
 
@@ -30,6 +31,11 @@ func New() *Trie {
 // InsertHandler inserts a new handler.
 func (t *Trie) InsertHandler(method string, path string, handler http.Handler) error {
 	tokens := t.tokenizePath(path)
+	for i, v := range tokens {
+		if v.kind == wildcard && i != len(tokens)-1 {
+			return fmt.Errorf("wildcard must be the last segment of the path. got=(%s)", path)
+		}
+	}
 
 	p := t.root
 	for _, v := range tokens {
@@ -50,6 +56,17 @@ func (t *Trie) InsertHandler(method string, path string, handler http.Handler) e
 			case exists && child.label == v.value:
 				p = child
 			}
+		case wildcard:
+			child, exists := p.children[wildcardLabel]
+			switch {
+			case !exists:
+				p.children[wildcardLabel] = newNode(v.value)
+				p = p.children[wildcardLabel]
+			case child.label != v.value:
+				return fmt.Errorf("wildcard name is differs with the previously registered. want=(%s), got=(%s)", child.label, v.value)
+			default:
+				p = child
+			}
 		}
 	}
 
@@ -57,21 +74,82 @@ func (t *Trie) InsertHandler(method string, path string, handler http.Handler) e
 		return fmt.Errorf("conflict handler. %s %s already has a handler", method, path)
 	}
 
+	p.methods = p.handlers.methods()
 	return nil
 }
 
 // FindHandler finds a handler.
 func (t *Trie) FindHandler(method string, path string) (http.Handler, Vars, error) {
+	res, err := t.Search(method, path)
+	return res.Handler, res.Vars, err
+}
+
+// Result holds the result of Search.
+type Result struct {
+	// Handler is the handler registered for the method and path.
+	Handler http.Handler
+
+	// Vars are the URL variables of the path.
+	Vars Vars
+
+	// Methods are the sorted methods registered for the path. It is shared
+	// between searches and must not be modified.
+	Methods []string
+
+	handlers handlers
+}
+
+// HandlerOf returns the handler registered for the path and the given
+// method, which can differ from the searched one.
+func (r Result) HandlerOf(method string) (http.Handler, bool) {
+	return r.handlers.get(method)
+}
+
+// Search finds a handler and the methods registered for the path. The
+// Methods is still set when the error is ErrMethodNotFound. A path matching
+// only a prefix of the registered paths has no method, so the error is
+// ErrPathNotFound rather than ErrMethodNotFound.
+//
+// Static segments take precedence over variables, which take precedence
+// over wildcards. A wildcard matches the rest of the path, including an
+// empty rest, and is used when the path can not be matched otherwise.
+func (t *Trie) Search(method string, path string) (Result, error) {
 	vars := make(Vars)
 	tokens := t.tokenizePath(path)
 
+	// fallback is the deepest wildcard seen, used when the path can not be
+	// matched by the static segments and variables.
+	var fallback struct {
+		node *node
+		vars Vars
+		rest []token
+	}
+
+	remember := func(p *node, rest []token) {
+		child, exists := p.children[wildcardLabel]
+		if !exists {
+			return
+		}
+
+		fallback.node = child
+		fallback.vars = make(Vars, len(vars)+1)
+		for k, v := range vars {
+			fallback.vars[k] = v
+		}
+		fallback.rest = rest
+	}
+
 	p := t.root
-	for _, v := range tokens {
+	found := true
+	for i, v := range tokens {
+		remember(p, tokens[i:])
+
 		nextNode, exists := p.children[v.value]
 		if !exists {
 			child, exists := p.children[varsLabel]
 			if !exists {
-				return nil, vars, ErrPathNotFound
+				found = false
+				break
 			}
 
 			p = child
@@ -82,12 +160,37 @@ func (t *Trie) FindHandler(method string, path string) (http.Handler, Vars, erro
 		p = nextNode
 	}
 
+	if found && len(p.methods) == 0 {
+		remember(p, nil)
+		found = false
+	}
+
+	if !found {
+		if fallback.node == nil {
+			return Result{Vars: vars}, ErrPathNotFound
+		}
+
+		segments := make([]string, len(fallback.rest))
+		for i, v := range fallback.rest {
+			segments[i] = strings.TrimPrefix(v.value, "/")
+		}
+
+		p, vars = fallback.node, fallback.vars
+		vars[p.label] = strings.Join(segments, "/")
+	}
+
+	res := Result{Vars: vars, Methods: p.methods, handlers: p.handlers}
+
 	handler, exists := p.handlers.get(method)
 	if !exists {
-		return nil, vars, ErrMethodNotFound
+		if len(p.methods) == 0 {
+			return res, ErrPathNotFound
+		}
+		return res, ErrMethodNotFound
 	}
 
-	return handler, vars, nil
+	res.Handler = handler
+	return res, nil
 }
 
 func (t *Trie) tokenizePath(p string) []token {
@@ -123,14 +226,27 @@ func (h handlers) get(method string) (http.Handler, bool) {
 	return handler, found
 }
 
+func (h handlers) methods() []string {
+	methods := make([]string, 0, len(h))
+	for m := range h {
+		methods = append(methods, m)
+	}
+
+	sort.Strings(methods)
+	return methods
+}
+
 const (
 	rootLabel = "<root>"
 	varsLabel = "<vars>"
+
+	wildcardLabel = "<wildcard>"
 )
 
 type node struct {
 	label    string
 	handlers handlers
+	methods  []string
 	children map[string]*node
 }
 
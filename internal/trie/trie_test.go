@@ -237,3 +237,84 @@ func assertNotNil(t *testing.T, err error) {
 		t.Fatalf("expecting error not nil")
 	}
 }
+
+func TestTrie_Search(t *testing.T) {
+	trie := New()
+	assertNil(t, trie.InsertHandler("POST", "/products", fakeHandler(0)))
+	assertNil(t, trie.InsertHandler("get", "/products", fakeHandler(1)))
+	assertNil(t, trie.InsertHandler("GET", "/products/:pid/stars", fakeHandler(2)))
+
+	res, err := trie.Search("PUT", "/products")
+	if err != ErrMethodNotFound {
+		t.Fatalf("expecting error %v; got %v", ErrMethodNotFound, err)
+	}
+
+	if exp := []string{"GET", "POST"}; !reflect.DeepEqual(res.Methods, exp) {
+		t.Fatalf("expecting methods %v; got %v", exp, res.Methods)
+	}
+
+	// intermediate nodes without handlers are not routes.
+	res, err = trie.Search("GET", "/products/1")
+	if err != ErrPathNotFound {
+		t.Fatalf("expecting error %v; got %v", ErrPathNotFound, err)
+	}
+
+	if len(res.Methods) != 0 {
+		t.Fatalf("expecting no methods; got %v", res.Methods)
+	}
+}
+
+func TestTrie_Wildcard(t *testing.T) {
+	trie := New()
+	assertNil(t, trie.InsertHandler("GET", "/assets/*filepath", fakeHandler(0)))
+	assertNil(t, trie.InsertHandler("GET", "/assets/app.js", fakeHandler(1)))
+	assertNil(t, trie.InsertHandler("GET", "/users/:id/files/*path", fakeHandler(2)))
+	assertNil(t, trie.InsertHandler("GET", "/*all", fakeHandler(3)))
+
+	tests := []struct {
+		method     string
+		path       string
+		expHandler http.Handler
+		expVars    Vars
+		expError   error
+	}{
+		{method: "GET", path: "/assets/css/app.css", expHandler: fakeHandler(0), expVars: Vars{"filepath": "css/app.css"}},
+		{method: "GET", path: "/assets/", expHandler: fakeHandler(0), expVars: Vars{"filepath": ""}},
+		{method: "GET", path: "/assets", expHandler: fakeHandler(0), expVars: Vars{"filepath": ""}},
+		{method: "GET", path: "/assets/app.js", expHandler: fakeHandler(1), expVars: Vars{}},
+		{method: "GET", path: "/assets/app.js/map", expHandler: fakeHandler(0), expVars: Vars{"filepath": "app.js/map"}},
+		{method: "GET", path: "/assets/../secret", expHandler: fakeHandler(3), expVars: Vars{"all": "secret"}},
+		{method: "GET", path: "/users/1/files/a/b", expHandler: fakeHandler(2), expVars: Vars{"id": "1", "path": "a/b"}},
+		{method: "GET", path: "/users/1/other", expHandler: fakeHandler(3), expVars: Vars{"all": "users/1/other"}},
+		{method: "GET", path: "/", expHandler: fakeHandler(3), expVars: Vars{"all": ""}},
+		{method: "POST", path: "/assets/app.css", expVars: Vars{"filepath": "app.css"}, expError: ErrMethodNotFound},
+	}
+
+	for i, tc := range tests {
+		res, err := trie.Search(tc.method, tc.path)
+		if err != tc.expError {
+			t.Fatalf("%d: expecting error %v; got %v", i, tc.expError, err)
+		}
+
+		if res.Handler != tc.expHandler {
+			t.Fatalf("%d: expecting handler %v; got %v", i, tc.expHandler, res.Handler)
+		}
+
+		if !reflect.DeepEqual(res.Vars, tc.expVars) {
+			t.Fatalf("%d: expecting vars %v; got %v", i, tc.expVars, res.Vars)
+		}
+	}
+}
+
+func TestTrie_Wildcard_Errors(t *testing.T) {
+	trie := New()
+	assertNil(t, trie.InsertHandler("GET", "/assets/*filepath", fakeHandler(0)))
+
+	if err := trie.InsertHandler("GET", "/assets/*name", fakeHandler(1)); err == nil {
+		t.Fatalf("expecting error for a different wildcard name")
+	}
+
+	if err := trie.InsertHandler("GET", "/files/*path/raw", fakeHandler(2)); err == nil {
+		t.Fatalf("expecting error for a wildcard not in the last segment")
+	}
+}
@@ -0,0 +1,89 @@
+package mux
+
+import (
+	"context"
+	"errors"
+
+	"github.com/josestg/mux/internal/trie"
+)
+
+var (
+	// ErrRouteNotFound is returned by Match when no route is registered for
+	// the path.
+	ErrRouteNotFound = errors.New("mux: route not found")
+
+	// ErrMethodNotAllowed is returned by Match when the path has routes but
+	// none for the method.
+	ErrMethodNotAllowed = errors.New("mux: method not allowed")
+)
+
+// MatchResult describes how a Mux would serve a request.
+type MatchResult struct {
+	// Route is the matched Route, or nil when there is no match.
+	Route *Route
+
+	// Vars are the URL variables of the path.
+	Vars map[string]string
+
+	// Methods are the sorted methods registered for the path. They are set
+	// on ErrMethodNotAllowed as well.
+	Methods []string
+
+	// Middlewares are the middlewares the request will go through, from the
+	// outermost. They also run when there is no match.
+	Middlewares []Middleware
+}
+
+// Match resolves the method and path like ServeHTTP does, without invoking
+// any handler or middleware. It returns ErrRouteNotFound or
+// ErrMethodNotAllowed when the request would be served by the
+// RoutesNotFoundHandler or the MethodNotFoundHandler. The feature flags of
+// the routes gated by WithFlag are evaluated with context.Background(), and
+// the path without version prefix is matched in the DefaultVersion.
+func (m *Mux) Match(method string, path string) (MatchResult, error) {
+	res, _, _, err := m.lookup(context.Background(), method, path, nil)
+
+	mr := MatchResult{
+		Vars:        res.Vars,
+		Methods:     append([]string(nil), res.Methods...),
+		Middlewares: make([]Middleware, 0, len(m.middlewares)),
+	}
+
+	for _, mw := range m.middlewares {
+		if mw != nil {
+			mr.Middlewares = append(mr.Middlewares, mw)
+		}
+	}
+
+	switch err {
+	case nil:
+		mr.Route = &res.Handler.(*route).Route
+		return mr, nil
+	case trie.ErrMethodNotFound:
+		return mr, ErrMethodNotAllowed
+	default:
+		return mr, ErrRouteNotFound
+	}
+}
+
+// Routes returns the registered routes: the ones of the Mux, then the ones
+// of each Version in the order they were registered, sorted by pattern and
+// method. It includes the routes gated by WithFlag whatever their flags.
+func (m *Mux) Routes() []*Route {
+	routes := m.table.list()
+	for _, v := range m.versions {
+		routes = append(routes, v.table.list()...)
+	}
+	return routes
+}
+
+// Lookup returns the Route registered for the method and path and the URL
+// variables of the path, without invoking its handler. The Route is nil when
+// nothing is registered for the method and path.
+func (m *Mux) Lookup(method string, path string) (*Route, map[string]string) {
+	mr, err := m.Match(method, path)
+	if err != nil {
+		return nil, nil
+	}
+	return mr.Route, mr.Vars
+}
@@ -0,0 +1,134 @@
+package mux_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+)
+
+func TestMux_Match(t *testing.T) {
+	var called bool
+	handler := mux.New()
+	handler.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			next.ServeHTTP(w, r)
+		})
+	})
+	handler.Use(mux.Timeout(0))
+
+	handler.Handle(http.MethodGet, "/books/:id", fakeHandler(0), mux.WithName("books.get"))
+	handler.Handle(http.MethodDelete, "/books/:id", fakeHandler(1))
+
+	tests := []struct {
+		method     string
+		path       string
+		expRoute   *mux.Route
+		expVars    map[string]string
+		expMethods []string
+		expErr     error
+	}{
+		{
+			method:     http.MethodGet,
+			path:       "/books/1",
+			expRoute:   &mux.Route{Method: http.MethodGet, Pattern: "/books/:id", Name: "books.get"},
+			expVars:    map[string]string{"id": "1"},
+			expMethods: []string{http.MethodDelete, http.MethodGet},
+		},
+		{
+			method:     http.MethodDelete,
+			path:       "/books/2",
+			expRoute:   &mux.Route{Method: http.MethodDelete, Pattern: "/books/:id"},
+			expVars:    map[string]string{"id": "2"},
+			expMethods: []string{http.MethodDelete, http.MethodGet},
+		},
+		{
+			method:     http.MethodPut,
+			path:       "/books/1",
+			expVars:    map[string]string{"id": "1"},
+			expMethods: []string{http.MethodDelete, http.MethodGet},
+			expErr:     mux.ErrMethodNotAllowed,
+		},
+		{
+			method:  http.MethodGet,
+			path:    "/authors",
+			expVars: map[string]string{},
+			expErr:  mux.ErrRouteNotFound,
+		},
+	}
+
+	for i, tc := range tests {
+		got, err := handler.Match(tc.method, tc.path)
+		if err != tc.expErr {
+			t.Fatalf("%d: expected error %v; got %v", i, tc.expErr, err)
+		}
+
+		if !reflect.DeepEqual(got.Route, tc.expRoute) {
+			t.Fatalf("%d: expected route %+v; got %+v", i, tc.expRoute, got.Route)
+		}
+
+		if !reflect.DeepEqual(map[string]string(got.Vars), tc.expVars) {
+			t.Fatalf("%d: expected vars %v; got %v", i, tc.expVars, got.Vars)
+		}
+
+		if !reflect.DeepEqual(got.Methods, tc.expMethods) {
+			t.Fatalf("%d: expected methods %v; got %v", i, tc.expMethods, got.Methods)
+		}
+
+		if len(got.Middlewares) != 2 {
+			t.Fatalf("%d: expected 2 middlewares; got %d", i, len(got.Middlewares))
+		}
+	}
+
+	if called {
+		t.Fatalf("expected middlewares not invoked")
+	}
+}
+
+func TestStatusCode_MatchErrors(t *testing.T) {
+	if got := mux.StatusCode(mux.ErrRouteNotFound); got != http.StatusNotFound {
+		t.Fatalf("expected %d; got %d", http.StatusNotFound, got)
+	}
+
+	if got := mux.StatusCode(mux.ErrMethodNotAllowed); got != http.StatusMethodNotAllowed {
+		t.Fatalf("expected %d; got %d", http.StatusMethodNotAllowed, got)
+	}
+}
+
+func TestMux_Routes(t *testing.T) {
+	deprecatedAt := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	off := func(context.Context, string) bool { return false }
+
+	handler := mux.New()
+	handler.Handle(http.MethodPost, "/books", fakeHandler(0))
+	handler.Handle(http.MethodGet, "/books", fakeHandler(1), mux.WithFlag(off, "books-v2"))
+	handler.Handle(http.MethodGet, "/books", fakeHandler(2), mux.WithName("books.list"))
+	handler.Handle(http.MethodGet, "/authors", fakeHandler(3), mux.WithDeprecation(deprecatedAt, time.Time{}, "authors.list"))
+	handler.Version("v2").Handle(http.MethodGet, "/authors", fakeHandler(4), mux.WithName("authors.list"))
+
+	exp := []string{
+		"GET /authors deprecated",
+		"GET /books",
+		"GET /books books.list",
+		"POST /books",
+		"v2 GET /authors authors.list",
+	}
+
+	routes := handler.Routes()
+	got := make([]string, len(routes))
+	for i, rt := range routes {
+		got[i] = strings.TrimSpace(rt.Version + " " + rt.Method + " " + rt.Pattern + " " + rt.Name)
+		if rt.Deprecation != nil {
+			got[i] += " deprecated"
+		}
+	}
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected routes %q; got %q", exp, got)
+	}
+}
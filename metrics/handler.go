@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler returns an http.Handler that exposes the collected metrics in the
+// Prometheus text exposition format.
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		w.WriteHeader(http.StatusOK)
+
+		bw := bufio.NewWriter(w)
+		c.writeTo(bw)
+		_ = bw.Flush()
+	})
+}
+
+func (c *Collector) writeTo(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := c.name("http_requests_total")
+	writeHeader(w, name, "Total number of HTTP requests.", "counter")
+	for _, s := range sortedSeries(c.requests) {
+		writeSample(w, name, s.labels(), float64(c.requests[s]))
+	}
+
+	name = c.name("http_request_duration_seconds")
+	writeHeader(w, name, "Latency of HTTP requests in seconds.", "histogram")
+	for _, s := range sortedSeries(c.durations) {
+		h := c.durations[s]
+		for i, le := range c.options.Buckets {
+			writeSample(w, name+"_bucket", s.labels("le", formatFloat(le)), float64(h.counts[i]))
+		}
+		writeSample(w, name+"_bucket", s.labels("le", "+Inf"), float64(h.count))
+		writeSample(w, name+"_sum", s.labels(), h.sum)
+		writeSample(w, name+"_count", s.labels(), float64(h.count))
+	}
+
+	name = c.name("http_requests_in_flight")
+	writeHeader(w, name, "Number of HTTP requests currently being served.", "gauge")
+	keys := make([]routeKey, 0, len(c.inflight))
+	for k := range c.inflight {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	for _, k := range keys {
+		writeSample(w, name, k.labels(), float64(c.inflight[k]))
+	}
+}
+
+func (c *Collector) name(s string) string {
+	if c.options.Namespace == "" {
+		return s
+	}
+	return c.options.Namespace + "_" + s
+}
+
+func (k routeKey) less(o routeKey) bool {
+	if k.route != o.route {
+		return k.route < o.route
+	}
+	return k.method < o.method
+}
+
+func (k routeKey) labels(extra ...string) []string {
+	return append([]string{"method", k.method, "route", k.route}, extra...)
+}
+
+func (s series) less(o series) bool {
+	if s.routeKey != o.routeKey {
+		return s.routeKey.less(o.routeKey)
+	}
+	return s.status < o.status
+}
+
+func (s series) labels(extra ...string) []string {
+	return s.routeKey.labels(append([]string{"status", s.status}, extra...)...)
+}
+
+func sortedSeries[V any](m map[series]V) []series {
+	keys := make([]series, 0, len(m))
+	for s := range m {
+		keys = append(keys, s)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	return keys
+}
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	w.WriteString("# HELP " + name + " " + help + "\n")
+	w.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+// writeSample writes a single sample line. The labels are given as
+// name-value pairs.
+func writeSample(w *bufio.Writer, name string, labels []string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(labels[i])
+			w.WriteString(`="`)
+			w.WriteString(labelEscaper.Replace(labels[i+1]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, +1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
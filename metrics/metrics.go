@@ -0,0 +1,209 @@
+// Package metrics provides a Mux middleware that records Prometheus-compatible
+// HTTP metrics and an http.Handler that exposes them in the Prometheus text
+// format.
+//
+// Requests are labelled by method, route pattern and status class, so the
+// number of series is bounded by the number of registered routes instead of
+// the number of distinct URL paths and methods. The pattern of a route
+// registered in a Version is prefixed by the version, e.g. /v2/books/:id.
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/josestg/mux"
+)
+
+// UnmatchedRoute is the route label used for requests that do not match any
+// registered route, e.g. 404 and 405 responses.
+const UnmatchedRoute = "<unmatched>"
+
+// OtherMethod is the method label used for requests whose method is neither
+// a standard HTTP method nor the method of the matched route, following the
+// OpenTelemetry semantic conventions.
+const OtherMethod = "_OTHER"
+
+// standardMethods are the methods defined by RFC 9110 and RFC 5789.
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// DefaultBuckets are the default latency histogram buckets in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// OptionApplier is a function for applying option.
+type OptionApplier func(o *Options)
+
+// Options holds Collector optional fields.
+type Options struct {
+	// Namespace is prepended to every metric name, e.g. "myapp" yields
+	// myapp_http_requests_total.
+	Namespace string
+
+	// Buckets are the upper bounds of the latency histogram in seconds.
+	Buckets []float64
+}
+
+// Default is a default option applier.
+func Default() OptionApplier {
+	return func(o *Options) {
+		o.Namespace = ""
+		o.Buckets = DefaultBuckets
+	}
+}
+
+// WithNamespace sets the metric name prefix.
+func WithNamespace(ns string) OptionApplier {
+	return func(o *Options) {
+		o.Namespace = ns
+	}
+}
+
+// WithBuckets sets the latency histogram buckets.
+func WithBuckets(buckets ...float64) OptionApplier {
+	return func(o *Options) {
+		o.Buckets = buckets
+	}
+}
+
+// Collector collects HTTP metrics of the requests passing through its
+// Middleware.
+type Collector struct {
+	options *Options
+	now     func() time.Time
+
+	mu        sync.Mutex
+	requests  map[series]uint64
+	durations map[series]*histogram
+	inflight  map[routeKey]int64
+}
+
+// New creates a new Collector with Default option.
+func New(appliers ...OptionApplier) *Collector {
+	var options Options
+	Default()(&options)
+
+	for _, apply := range appliers {
+		apply(&options)
+	}
+
+	buckets := make([]float64, len(options.Buckets))
+	copy(buckets, options.Buckets)
+	sort.Float64s(buckets)
+	options.Buckets = buckets
+
+	return &Collector{
+		options:   &options,
+		now:       time.Now,
+		requests:  make(map[series]uint64),
+		durations: make(map[series]*histogram),
+		inflight:  make(map[routeKey]int64),
+	}
+}
+
+// Middleware records the request count, latency and in-flight requests of
+// the given handler. It implements the mux.Middleware interface.
+func (c *Collector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rk := routeKey{method: methodLabel(r), route: routeLabel(r)}
+
+		c.mu.Lock()
+		c.inflight[rk]++
+		c.mu.Unlock()
+
+		start := c.now()
+		rw := mux.NewResponseWriter(w)
+
+		defer func() {
+			elapsed := c.now().Sub(start).Seconds()
+			s := series{routeKey: rk, status: statusClass(rw.Status())}
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			c.inflight[rk]--
+			c.requests[s]++
+
+			h, ok := c.durations[s]
+			if !ok {
+				h = newHistogram(len(c.options.Buckets))
+				c.durations[s] = h
+			}
+			h.observe(c.options.Buckets, elapsed)
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// methodLabel bounds the method label, since any client can send arbitrary
+// methods.
+func methodLabel(r *http.Request) string {
+	if standardMethods[r.Method] {
+		return r.Method
+	}
+
+	if rt := mux.GetRoute(r.Context()); rt != nil && strings.EqualFold(rt.Method, r.Method) {
+		return r.Method
+	}
+	return OtherMethod
+}
+
+func routeLabel(r *http.Request) string {
+	if rt := mux.GetRoute(r.Context()); rt != nil {
+		return rt.VersionedPattern()
+	}
+	return UnmatchedRoute
+}
+
+func statusClass(code int) string {
+	switch {
+	case code >= 100 && code < 600:
+		return strconv.Itoa(code/100) + "xx"
+	default:
+		return "unknown"
+	}
+}
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+type series struct {
+	routeKey
+	status string
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(n int) *histogram {
+	return &histogram{counts: make([]uint64, n)}
+}
+
+func (h *histogram) observe(buckets []float64, v float64) {
+	for i, le := range buckets {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
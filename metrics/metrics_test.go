@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+)
+
+func TestCollector(t *testing.T) {
+	c := New(WithNamespace("app"), WithBuckets(0.5, 0.1, 1))
+
+	// every call to now advances the clock by 200ms, so each request
+	// takes exactly 200ms.
+	clock := time.Unix(0, 0)
+	c.now = func() time.Time {
+		clock = clock.Add(200 * time.Millisecond)
+		return clock
+	}
+
+	m := mux.New()
+	m.Use(c.Middleware)
+	m.HandleFunc(http.MethodGet, "/books/:id", func(w http.ResponseWriter, r *http.Request) {
+		if mux.GetVars(r.Context()).Get("id") == "0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	for _, path := range []string{"/books/1", "/books/2", "/books/0", "/unknown"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Fatalf("expected content type %q; got %q", ContentType, got)
+	}
+
+	body := rec.Body.String()
+	expected := []string{
+		"# TYPE app_http_requests_total counter",
+		`app_http_requests_total{method="GET",route="/books/:id",status="2xx"} 2`,
+		`app_http_requests_total{method="GET",route="/books/:id",status="4xx"} 1`,
+		`app_http_requests_total{method="GET",route="<unmatched>",status="4xx"} 1`,
+		"# TYPE app_http_request_duration_seconds histogram",
+		`app_http_request_duration_seconds_bucket{method="GET",route="/books/:id",status="2xx",le="0.1"} 0`,
+		`app_http_request_duration_seconds_bucket{method="GET",route="/books/:id",status="2xx",le="0.5"} 2`,
+		`app_http_request_duration_seconds_bucket{method="GET",route="/books/:id",status="2xx",le="1"} 2`,
+		`app_http_request_duration_seconds_bucket{method="GET",route="/books/:id",status="2xx",le="+Inf"} 2`,
+		`app_http_request_duration_seconds_sum{method="GET",route="/books/:id",status="2xx"} 0.4`,
+		`app_http_request_duration_seconds_count{method="GET",route="/books/:id",status="2xx"} 2`,
+		"# TYPE app_http_requests_in_flight gauge",
+		`app_http_requests_in_flight{method="GET",route="/books/:id"} 0`,
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected line %q in:\n%s", line, body)
+		}
+	}
+
+	if strings.Contains(body, "/unknown") || strings.Contains(body, "/books/1") {
+		t.Fatalf("raw paths must not be used as labels:\n%s", body)
+	}
+}
+
+func TestCollector_Methods(t *testing.T) {
+	c := New()
+
+	m := mux.New()
+	m.Use(c.Middleware)
+	m.HandleFunc(http.MethodGet, "/books", func(w http.ResponseWriter, r *http.Request) {})
+	m.HandleFunc("PROPFIND", "/books", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, method := range []string{"GET", "PROPFIND", "X", "XA", "XAA", "DELETE"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/books", nil))
+	}
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	expected := []string{
+		`http_requests_total{method="GET",route="/books",status="2xx"} 1`,
+		`http_requests_total{method="PROPFIND",route="/books",status="2xx"} 1`,
+		`http_requests_total{method="_OTHER",route="<unmatched>",status="4xx"} 3`,
+		`http_requests_total{method="DELETE",route="<unmatched>",status="4xx"} 1`,
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected line %q in:\n%s", line, body)
+		}
+	}
+
+	if strings.Contains(body, `method="X`) {
+		t.Fatalf("arbitrary methods must not be used as labels:\n%s", body)
+	}
+}
+
+func TestCollector_Versions(t *testing.T) {
+	c := New()
+
+	m := mux.New()
+	m.Use(c.Middleware)
+	m.Version("v1").HandleFunc(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+	m.Version("v2").HandleFunc(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/v1/users/1", "/v2/users/1", "/v2/users/2"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	expected := []string{
+		`http_requests_total{method="GET",route="/v1/users/:id",status="2xx"} 1`,
+		`http_requests_total{method="GET",route="/v2/users/:id",status="2xx"} 2`,
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected line %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestCollector_InFlight(t *testing.T) {
+	c := New()
+
+	m := mux.New()
+	m.Use(c.Middleware)
+	m.HandleFunc(http.MethodGet, "/slow", func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		c.Handler().ServeHTTP(rec, r)
+
+		line := `http_requests_in_flight{method="GET",route="/slow"} 1` + "\n"
+		if !strings.Contains(rec.Body.String(), line) {
+			t.Errorf("expected line %q in:\n%s", line, rec.Body.String())
+		}
+	})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+}
+
+func TestWriteSample_EscapeLabels(t *testing.T) {
+	c := New()
+	c.requests[series{routeKey: routeKey{method: "GET", route: "a\"b\\c\nd"}, status: "2xx"}] = 1
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	exp := `http_requests_total{method="GET",route="a\"b\\c\nd",status="2xx"} 1`
+	if !strings.Contains(rec.Body.String(), exp) {
+		t.Fatalf("expected %q in:\n%s", exp, rec.Body.String())
+	}
+}
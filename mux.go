@@ -3,6 +3,7 @@ package mux
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"github.com/josestg/mux/internal/trie"
 )
@@ -11,7 +12,8 @@ import (
 // request against a list of registered patterns and calls the handler for the
 // pattern that matches the URL.
 type Mux struct {
-	router      *trie.Trie
+	table       *routeTable
+	versions    []*Version
 	options     *Options
 	middlewares []Middleware
 }
@@ -25,28 +27,30 @@ func New(appliers ...OptionApplier) *Mux {
 	}
 
 	return &Mux{
-		router:      trie.New(),
+		table:       newRouteTable(),
 		options:     options,
 		middlewares: make([]Middleware, 0),
 	}
 }
 
 // Handle registers the http.Handler for the given HTTP method and URL path.
-func (m *Mux) Handle(method string, path string, handler http.Handler) {
-	if err := m.router.InsertHandler(method, path, handler); err != nil {
+// The route options are applied to the registered Route.
+func (m *Mux) Handle(method string, path string, handler http.Handler, appliers ...RouteOptionApplier) {
+	if err := m.table.insert(newRoute(method, path, handler, appliers)); err != nil {
 		panic(err)
 	}
 }
 
 // HandleFunc registers the http.HandlerFunc for the given HTTP method
 // and URL path.
-func (m *Mux) HandleFunc(method string, path string, handlerFunc http.HandlerFunc) {
-	m.Handle(method, path, handlerFunc)
+func (m *Mux) HandleFunc(method string, path string, handlerFunc http.HandlerFunc, appliers ...RouteOptionApplier) {
+	m.Handle(method, path, handlerFunc, appliers...)
 }
 
 // ServeHTTP implements the http.Handler interface.
 func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	handler, vars, err := m.router.FindHandler(r.Method, r.URL.Path)
+	res, version, prefixed, err := m.lookup(r.Context(), r.Method, r.URL.Path, r.Header)
+	handler, vars := res.Handler, res.Vars
 	if err != nil {
 		switch err {
 		case trie.ErrMethodNotFound:
@@ -63,28 +67,92 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	ctx := contextWithVars(r.Context(), vars)
-	wrappedHandler.ServeHTTP(w, r.WithContext(ctx))
+	info := &requestInfo{vars: vars, methods: res.Methods, options: m.options}
+	if rt, ok := handler.(*route); ok {
+		info.route = &rt.Route
+	}
+
+	if version != nil {
+		info.version = version.name
+		version.setHeaders(w.Header())
+		if !prefixed {
+			m.varyVersion(w.Header())
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), requestContextKey, info)
+	r = r.WithContext(ctx)
+	if info.route != nil && info.route.Deprecation != nil {
+		m.deprecate(w, r, info.route)
+	}
+	if m.options.Tracer != nil {
+		var end func()
+		w, r, end = m.startSpan(w, r)
+		defer end()
+	}
+
+	wrappedHandler.ServeHTTP(w, r)
 }
 
 func (m *Mux) useMiddleware(mw Middleware) {
 	m.middlewares = append(m.middlewares, mw)
 }
 
-type contextType struct{}
+type contextType int
 
-var (
-	varsContextKey = new(contextType)
+const (
+	requestContextKey contextType = iota
+	spanContextKey
 )
 
-func contextWithVars(ctx context.Context, vars trie.Vars) context.Context {
-	return context.WithValue(ctx, varsContextKey, vars)
+// requestInfo holds the routing information of a request. It is stored in
+// the request context by ServeHTTP.
+type requestInfo struct {
+	vars    trie.Vars
+	route   *Route
+	methods []string
+	options *Options
+
+	// variant is the name of the variant selected by HandleVariants.
+	variant string
+
+	// version is the name of the Version selected by the request.
+	version string
+}
+
+func getRequestInfo(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(requestContextKey).(*requestInfo)
+	if info == nil {
+		return &requestInfo{}
+	}
+	return info
 }
 
 // GetVars returns URL variables.
 func GetVars(ctx context.Context) trie.Vars {
-	vars, _ := ctx.Value(varsContextKey).(trie.Vars)
-	return vars
+	return getRequestInfo(ctx).vars
+}
+
+// ContextWithVars returns a copy of ctx carrying the URL variables returned
+// by GetVars. It lets handlers be tested without routing the request through
+// a Mux.
+func ContextWithVars(ctx context.Context, vars map[string]string) context.Context {
+	info := *getRequestInfo(ctx)
+	info.vars = vars
+	return context.WithValue(ctx, requestContextKey, &info)
+}
+
+// GetAllowedMethods returns the sorted methods registered for the request
+// path. It returns nil when the path does not match any registered route.
+func GetAllowedMethods(ctx context.Context) []string {
+	methods := getRequestInfo(ctx).methods
+	if len(methods) == 0 {
+		return nil
+	}
+
+	cp := make([]string, len(methods))
+	copy(cp, methods)
+	return cp
 }
 
 // OptionApplier is a function for applying option.
@@ -94,17 +162,104 @@ type OptionApplier func(o *Options)
 type Options struct {
 	RoutesNotFoundHandler http.Handler
 	MethodNotFoundHandler http.Handler
+
+	// Tracer, when set, starts a span around every dispatched request.
+	Tracer Tracer
+
+	// ErrorHandler writes the response of the errors returned by HandlerE.
+	ErrorHandler ErrorHandler
+
+	// ProblemRenderers render the errors generated by the router and the
+	// built-in middlewares in the format negotiated with the Accept header.
+	// On a tie, the first renderer wins.
+	ProblemRenderers []ProblemRenderer
+
+	// Encoders encode the values written by Render in the format negotiated
+	// with the Accept header. On a tie, the first encoder wins.
+	Encoders []Encoder
+
+	// VersionHeader and VersionParam are the request header and the Accept
+	// media type parameter selecting the Version of the requests whose path
+	// has no version prefix.
+	VersionHeader string
+	VersionParam  string
+
+	// DefaultVersion is the Version of the requests selecting none. The
+	// first registered Version when empty.
+	DefaultVersion string
+
+	// DeprecationHook, when set, is called for every request served by a
+	// Route deprecated by WithDeprecation.
+	DeprecationHook DeprecationHook
 }
 
 // Default is a default option applier.
 func Default() OptionApplier {
 	return func(o *Options) {
 		o.RoutesNotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			Error(w, r, http.StatusNotFound)
 		})
 		o.MethodNotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			w.Header().Set("Allow", strings.Join(GetAllowedMethods(r.Context()), ", "))
+			Error(w, r, http.StatusMethodNotAllowed)
 		})
+		o.ErrorHandler = DefaultErrorHandler
+		o.ProblemRenderers = []ProblemRenderer{TextProblemRenderer{}, JSONProblemRenderer{}}
+		o.Encoders = []Encoder{JSONEncoder{}, XMLEncoder{}, MessagePackEncoder{}, CBOREncoder{}, TextEncoder{}}
+		o.VersionHeader = "API-Version"
+		o.VersionParam = "version"
+	}
+}
+
+// WithTracer is an option applier for setting the Tracer.
+func WithTracer(t Tracer) OptionApplier {
+	return func(o *Options) {
+		o.Tracer = t
+	}
+}
+
+// WithErrorHandler is an option applier for setting the ErrorHandler.
+func WithErrorHandler(h ErrorHandler) OptionApplier {
+	return func(o *Options) {
+		o.ErrorHandler = h
+	}
+}
+
+// WithProblemRenderer is an option applier for adding a ProblemRenderer.
+func WithProblemRenderer(pr ProblemRenderer) OptionApplier {
+	return func(o *Options) {
+		o.ProblemRenderers = append(o.ProblemRenderers, pr)
+	}
+}
+
+// WithEncoders is an option applier for replacing the Encoders. The first
+// encoder is used when the client accepts any content type.
+func WithEncoders(encoders ...Encoder) OptionApplier {
+	return func(o *Options) {
+		o.Encoders = encoders
+	}
+}
+
+// WithVersionSelection is an option applier for setting the VersionHeader
+// and the VersionParam. An empty value disables the selection.
+func WithVersionSelection(header string, param string) OptionApplier {
+	return func(o *Options) {
+		o.VersionHeader = header
+		o.VersionParam = param
+	}
+}
+
+// WithDefaultVersion is an option applier for setting the DefaultVersion.
+func WithDefaultVersion(name string) OptionApplier {
+	return func(o *Options) {
+		o.DefaultVersion = name
+	}
+}
+
+// WithDeprecationHook is an option applier for setting the DeprecationHook.
+func WithDeprecationHook(hook DeprecationHook) OptionApplier {
+	return func(o *Options) {
+		o.DeprecationHook = hook
 	}
 }
 
@@ -159,3 +159,56 @@ func TestMux_ServeHTTP(t *testing.T) {
 	}
 
 }
+
+func TestGetRoute(t *testing.T) {
+	handler := mux.New()
+
+	var got *mux.Route
+	handler.HandleFunc(http.MethodGet, "/books/:id", func(w http.ResponseWriter, r *http.Request) {
+		got = mux.GetRoute(r.Context())
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/books/1", nil))
+
+	exp := &mux.Route{Method: http.MethodGet, Pattern: "/books/:id"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %+v; got %+v", exp, got)
+	}
+}
+
+func TestMux_MethodNotFound_Allow(t *testing.T) {
+	handler := mux.New()
+	handler.Handle(http.MethodGet, "/books", fakeHandler(0))
+	handler.Handle(http.MethodPost, "/books", fakeHandler(1))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/books", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected %d; got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+
+	if got := rec.Header().Get("Allow"); got != "GET, POST" {
+		t.Fatalf("expected %q; got %q", "GET, POST", got)
+	}
+}
+
+func TestMux_RoutePrefixNotFound(t *testing.T) {
+	handler := mux.New()
+	handler.Handle(http.MethodGet, "/books/:id/reviews", fakeHandler(0))
+
+	// a path matching only a prefix of a route is not a route, so no
+	// method is allowed for it.
+	for i, path := range []string{"/books", "/books/1"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("%d: expected status code %d; got %d", i, http.StatusNotFound, rec.Code)
+		}
+
+		if got := rec.Header().Get("Allow"); got != "" {
+			t.Fatalf("%d: expected no Allow; got %q", i, got)
+		}
+	}
+}
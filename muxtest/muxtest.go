@@ -0,0 +1,70 @@
+// Package muxtest provides utilities for testing routes and handlers of a
+// Mux without starting a server.
+//
+// Routing can be asserted without invoking the handlers:
+//
+//	m := mux.New()
+//	m.Handle(http.MethodGet, "/books/:id", getBook, mux.WithName("books.get"))
+//	muxtest.AssertRoute(t, m, http.MethodGet, "/books/1", "books.get", map[string]string{"id": "1"})
+//
+// Handlers can be tested in isolation with the URL variables they expect:
+//
+//	r := muxtest.WithVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"id": "1"})
+//
+// And requests can be served and asserted fluently:
+//
+//	muxtest.NewRequest(t, m, http.MethodGet, "/books/1").
+//		Header("Accept", "application/json").
+//		Do().
+//		Status(http.StatusOK).
+//		Header("Content-Type", "application/json")
+package muxtest
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/josestg/mux"
+)
+
+// WithVars returns a shallow copy of r carrying the URL variables returned
+// by mux.GetVars.
+func WithVars(r *http.Request, vars map[string]string) *http.Request {
+	return r.WithContext(mux.ContextWithVars(r.Context(), vars))
+}
+
+// AssertRoute asserts that m resolves the method and path to the route named
+// name with the given URL variables. The handler is not invoked. A nil vars
+// expects the path to have no variables.
+func AssertRoute(t testing.TB, m *mux.Mux, method, path, name string, vars map[string]string) {
+	t.Helper()
+
+	rt, got := m.Lookup(method, path)
+	if rt == nil {
+		t.Errorf("%s %s: expected route %q; got no route", method, path, name)
+		return
+	}
+
+	if rt.Name != name {
+		t.Errorf("%s %s: expected route %q; got %q (%s)", method, path, name, rt.Name, rt.Pattern)
+	}
+
+	if len(vars) == 0 && len(got) == 0 {
+		return
+	}
+
+	if !reflect.DeepEqual(map[string]string(got), vars) {
+		t.Errorf("%s %s: expected vars %v; got %v", method, path, vars, got)
+	}
+}
+
+// AssertNoRoute asserts that m does not resolve the method and path to any
+// route.
+func AssertNoRoute(t testing.TB, m *mux.Mux, method, path string) {
+	t.Helper()
+
+	if rt, _ := m.Lookup(method, path); rt != nil {
+		t.Errorf("%s %s: expected no route; got %q (%s)", method, path, rt.Name, rt.Pattern)
+	}
+}
@@ -0,0 +1,140 @@
+package muxtest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/muxtest"
+)
+
+// recorderTB records the reported failures instead of failing the test.
+type recorderTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorderTB) Helper() {}
+
+func (r *recorderTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorderTB) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+}
+
+func newMux(called *bool) *mux.Mux {
+	m := mux.New()
+	h := func(w http.ResponseWriter, r *http.Request) {
+		*called = true
+	}
+	m.HandleFunc(http.MethodGet, "/books", h, mux.WithName("books.list"))
+	m.HandleFunc(http.MethodGet, "/books/:id", h, mux.WithName("books.get"))
+	m.HandleFunc(http.MethodPost, "/books/:id/reviews", h, mux.WithName("reviews.create"))
+	return m
+}
+
+func TestAssertRoute(t *testing.T) {
+	var called bool
+	m := newMux(&called)
+
+	tests := []struct {
+		method    string
+		path      string
+		name      string
+		vars      map[string]string
+		expFailed bool
+	}{
+		{method: http.MethodGet, path: "/books", name: "books.list"},
+		{method: http.MethodGet, path: "/books/1", name: "books.get", vars: map[string]string{"id": "1"}},
+		{method: http.MethodPost, path: "/books/1/reviews", name: "reviews.create", vars: map[string]string{"id": "1"}},
+		{method: http.MethodGet, path: "/books/1", name: "books.list", vars: map[string]string{"id": "1"}, expFailed: true},
+		{method: http.MethodGet, path: "/books/1", name: "books.get", vars: map[string]string{"id": "2"}, expFailed: true},
+		{method: http.MethodGet, path: "/books/1/reviews", name: "reviews.create", expFailed: true},
+		{method: http.MethodGet, path: "/authors", name: "authors.list", expFailed: true},
+	}
+
+	for i, tc := range tests {
+		rec := &recorderTB{TB: t}
+		muxtest.AssertRoute(rec, m, tc.method, tc.path, tc.name, tc.vars)
+
+		if failed := len(rec.errors) > 0; failed != tc.expFailed {
+			t.Fatalf("%d: expected failed %v; got %v (%v)", i, tc.expFailed, failed, rec.errors)
+		}
+	}
+
+	if called {
+		t.Fatalf("expected handlers not invoked")
+	}
+}
+
+func TestAssertNoRoute(t *testing.T) {
+	var called bool
+	m := newMux(&called)
+
+	rec := &recorderTB{TB: t}
+	muxtest.AssertNoRoute(rec, m, http.MethodGet, "/authors")
+	muxtest.AssertNoRoute(rec, m, http.MethodDelete, "/books")
+	if len(rec.errors) != 0 {
+		t.Fatalf("expected no failures; got %v", rec.errors)
+	}
+
+	muxtest.AssertNoRoute(rec, m, http.MethodGet, "/books")
+	if len(rec.errors) != 1 {
+		t.Fatalf("expected 1 failure; got %v", rec.errors)
+	}
+}
+
+func TestWithVars(t *testing.T) {
+	r := muxtest.WithVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"id": "1"})
+
+	if got := mux.GetVars(r.Context()).Get("id"); got != "1" {
+		t.Fatalf("expected %q; got %q", "1", got)
+	}
+}
+
+func TestRequest(t *testing.T) {
+	m := mux.New()
+	m.HandleFunc(http.MethodPost, "/books/:id", func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		in["id"] = mux.GetVars(r.Context()).Get("id")
+		_ = mux.Render(w, r, http.StatusCreated, in)
+	})
+
+	muxtest.NewRequest(t, m, http.MethodPost, "/books/1").
+		JSON(map[string]any{"title": "Go"}).
+		Header("Accept", "application/json").
+		Do().
+		Status(http.StatusCreated).
+		Header("Content-Type", "application/json").
+		BodyContains(`"title":"Go"`).
+		JSON(map[string]any{"id": "1", "title": "Go"})
+
+	rec := &recorderTB{TB: t}
+	muxtest.NewRequest(rec, m, http.MethodGet, "/books/1").
+		Do().
+		Status(http.StatusOK).
+		Header("Allow", "GET").
+		Body("")
+
+	if len(rec.errors) != 3 {
+		t.Fatalf("expected 3 failures; got %v", rec.errors)
+	}
+}
+
+func TestRequest_Vars(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(mux.GetVars(r.Context()).Get("id")))
+	})
+
+	muxtest.NewRequest(t, h, http.MethodGet, "/").
+		Vars(map[string]string{"id": "42"}).
+		Do().
+		Status(http.StatusOK).
+		Body("42")
+}
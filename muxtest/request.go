@@ -0,0 +1,151 @@
+package muxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Request is a request under construction. It is served by Do.
+type Request struct {
+	t       testing.TB
+	handler http.Handler
+	req     *http.Request
+}
+
+// NewRequest creates a Request for the method and target served by handler.
+// Failures are reported to t.
+func NewRequest(t testing.TB, handler http.Handler, method, target string) *Request {
+	return &Request{
+		t:       t,
+		handler: handler,
+		req:     httptest.NewRequest(method, target, nil),
+	}
+}
+
+// Header sets the request header.
+func (r *Request) Header(key, value string) *Request {
+	r.req.Header.Set(key, value)
+	return r
+}
+
+// Body sets the request body.
+func (r *Request) Body(body string) *Request {
+	r.req.Body = io.NopCloser(strings.NewReader(body))
+	r.req.ContentLength = int64(len(body))
+	return r
+}
+
+// JSON sets the JSON encoding of v as the request body.
+func (r *Request) JSON(v any) *Request {
+	r.t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		r.t.Fatalf("encode request body: %v", err)
+	}
+
+	r.req.Header.Set("Content-Type", "application/json")
+	return r.Body(string(b))
+}
+
+// Vars sets the URL variables of the request, for handlers served without
+// a Mux.
+func (r *Request) Vars(vars map[string]string) *Request {
+	r.req = WithVars(r.req, vars)
+	return r
+}
+
+// Do serves the request and returns the recorded Response.
+func (r *Request) Do() *Response {
+	rec := httptest.NewRecorder()
+	r.handler.ServeHTTP(rec, r.req)
+	return &Response{t: r.t, Recorder: rec}
+}
+
+// Response is a served response with assertions. Failed assertions are
+// reported with Errorf, so all of them are checked.
+type Response struct {
+	t testing.TB
+
+	// Recorder is the recorder of the response.
+	Recorder *httptest.ResponseRecorder
+}
+
+// Status asserts the status code.
+func (r *Response) Status(code int) *Response {
+	r.t.Helper()
+
+	if r.Recorder.Code != code {
+		r.t.Errorf("expected status code %d; got %d", code, r.Recorder.Code)
+	}
+	return r
+}
+
+// Header asserts the value of the header.
+func (r *Response) Header(key, value string) *Response {
+	r.t.Helper()
+
+	if got := r.Recorder.Header().Get(key); got != value {
+		r.t.Errorf("expected header %s %q; got %q", key, value, got)
+	}
+	return r
+}
+
+// Body asserts the body.
+func (r *Response) Body(body string) *Response {
+	r.t.Helper()
+
+	if got := r.Recorder.Body.String(); got != body {
+		r.t.Errorf("expected body %q; got %q", body, got)
+	}
+	return r
+}
+
+// BodyContains asserts that the body contains s.
+func (r *Response) BodyContains(s string) *Response {
+	r.t.Helper()
+
+	if got := r.Recorder.Body.String(); !strings.Contains(got, s) {
+		r.t.Errorf("expected body containing %q; got %q", s, got)
+	}
+	return r
+}
+
+// JSON asserts that the body is the JSON encoding of v. The values are
+// compared after decoding, so the formatting and key order do not matter.
+func (r *Response) JSON(v any) *Response {
+	r.t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		r.t.Fatalf("encode expected body: %v", err)
+	}
+
+	var exp, got any
+	_ = json.Unmarshal(b, &exp)
+	if err := json.Unmarshal(r.Recorder.Body.Bytes(), &got); err != nil {
+		r.t.Errorf("expected JSON body; got %q", r.Recorder.Body.String())
+		return r
+	}
+
+	if !reflect.DeepEqual(exp, got) {
+		r.t.Errorf("expected body %s; got %s", b, bytes.TrimSpace(r.Recorder.Body.Bytes()))
+	}
+	return r
+}
+
+// Decode decodes the JSON body into v.
+func (r *Response) Decode(v any) *Response {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.Recorder.Body.Bytes(), v); err != nil {
+		r.t.Errorf("decode body %q: %v", r.Recorder.Body.String(), err)
+	}
+	return r
+}
@@ -0,0 +1,151 @@
+package mux
+
+import (
+	"strconv"
+	"strings"
+)
+
+// acceptRange is a single media range of an Accept header.
+type acceptRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// specificity is 0 for */*, 1 for type/* and 2 for type/subtype.
+func (a acceptRange) specificity() int {
+	switch {
+	case a.typ == "*":
+		return 0
+	case a.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (a acceptRange) match(mediaType string) bool {
+	typ, subtype := splitMediaType(mediaType)
+	return (a.typ == "*" || a.typ == typ) && (a.subtype == "*" || a.subtype == subtype)
+}
+
+func splitMediaType(mediaType string) (string, string) {
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	typ, subtype, ok := strings.Cut(mediaType, "/")
+	if !ok {
+		return mediaType, ""
+	}
+	return typ, subtype
+}
+
+func parseAccept(header string) []acceptRange {
+	if strings.TrimSpace(header) == "" {
+		return []acceptRange{{typ: "*", subtype: "*", q: 1}}
+	}
+
+	ranges := make([]acceptRange, 0, strings.Count(header, ",")+1)
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+
+		typ, subtype := splitMediaType(params[0])
+		if typ == "" || subtype == "" {
+			continue
+		}
+
+		a := acceptRange{typ: typ, subtype: subtype, q: 1}
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "q") {
+				if q, err := strconv.ParseFloat(v, 64); err == nil && q >= 0 && q <= 1 {
+					a.q = q
+				}
+			}
+		}
+
+		ranges = append(ranges, a)
+	}
+
+	return ranges
+}
+
+// quality returns the quality of mediaType given by its most specific
+// matching range, and that specificity. The quality is zero when no range
+// matches.
+func quality(ranges []acceptRange, mediaType string) (float64, int) {
+	q, spec := 0.0, -1
+	for _, a := range ranges {
+		if s := a.specificity(); s > spec && a.match(mediaType) {
+			q, spec = a.q, s
+		}
+	}
+	return q, spec
+}
+
+// negotiate returns the index of the offer preferred by the Accept header,
+// or -1 when none is acceptable. Offers with the same quality are ordered by
+// the specificity of their matching range, then by their position.
+func negotiate(accept string, offers []string) int {
+	ranges := parseAccept(accept)
+
+	best, bestQ, bestSpec := -1, 0.0, -1
+	for i, offer := range offers {
+		q, spec := quality(ranges, offer)
+		if q > bestQ || (q == bestQ && q > 0 && spec > bestSpec) {
+			best, bestQ, bestSpec = i, q, spec
+		}
+	}
+
+	return best
+}
+
+// NegotiateEncoding returns the index of the content coding with the
+// highest quality in the Accept-Encoding header, the first one on a tie, or
+// -1 when none is accepted.
+func NegotiateEncoding(header string, codings []string) int {
+	best, bestQ := -1, 0.0
+	for i, c := range codings {
+		if q := encodingQuality(header, c); q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	return best
+}
+
+// encodingQuality returns the quality of the content coding given by the
+// Accept-Encoding header. An explicit coding takes precedence over "*", and
+// the quality is zero when the coding is not accepted.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9110#name-accept-encoding.
+func encodingQuality(header, coding string) float64 {
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		name := strings.TrimSpace(params[0])
+
+		pq := 1.0
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "q") {
+				if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+					pq = f
+				}
+			}
+		}
+
+		switch {
+		case strings.EqualFold(name, coding):
+			return pq
+		case name == "*":
+			wildcard = pq
+		}
+	}
+
+	if wildcard >= 0 {
+		return wildcard
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+package mux
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"text/plain", "application/json", "application/xml"}
+
+	tests := []struct {
+		accept string
+		exp    int
+	}{
+		{accept: "", exp: 0},
+		{accept: "*/*", exp: 0},
+		{accept: "application/json", exp: 1},
+		{accept: "application/*", exp: 1},
+		{accept: "application/*, application/xml", exp: 2},
+		{accept: "application/xml;q=0.9, application/json", exp: 1},
+		{accept: "application/json;q=0.1, */*;q=0.5", exp: 0},
+		{accept: "text/plain;q=0, */*", exp: 1},
+		{accept: "Application/JSON; charset=utf-8", exp: 1},
+		{accept: "image/png", exp: -1},
+		{accept: "application/json;q=0", exp: -1},
+		{accept: "invalid, application/xml", exp: 2},
+	}
+
+	for _, tc := range tests {
+		if got := negotiate(tc.accept, offers); got != tc.exp {
+			t.Errorf("%q: expected %d; got %d", tc.accept, tc.exp, got)
+		}
+	}
+}
+
+func TestEncodingQuality(t *testing.T) {
+	tests := []struct {
+		accept string
+		coding string
+		exp    float64
+	}{
+		{accept: "", coding: "gzip", exp: 0},
+		{accept: "gzip", coding: "gzip", exp: 1},
+		{accept: "GZIP", coding: "gzip", exp: 1},
+		{accept: "br;q=0.5, gzip", coding: "br", exp: 0.5},
+		{accept: "gzip;q=0", coding: "gzip", exp: 0},
+		{accept: "*", coding: "br", exp: 1},
+		{accept: "*;q=0.2, gzip;q=0", coding: "gzip", exp: 0},
+		{accept: "*;q=0.2, gzip;q=0", coding: "br", exp: 0.2},
+		{accept: "deflate", coding: "gzip", exp: 0},
+	}
+
+	for _, tc := range tests {
+		if got := encodingQuality(tc.accept, tc.coding); got != tc.exp {
+			t.Errorf("%q %s: expected %v; got %v", tc.accept, tc.coding, tc.exp, got)
+		}
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	codings := []string{"gzip", "deflate"}
+
+	tests := []struct {
+		accept string
+		exp    int
+	}{
+		{accept: "", exp: -1},
+		{accept: "br", exp: -1},
+		{accept: "gzip, deflate", exp: 0},
+		{accept: "gzip;q=0.5, deflate", exp: 1},
+		{accept: "*", exp: 0},
+		{accept: "*;q=0.5, deflate", exp: 1},
+		{accept: "*, gzip;q=0", exp: 1},
+	}
+
+	for _, tc := range tests {
+		if got := NegotiateEncoding(tc.accept, codings); got != tc.exp {
+			t.Errorf("%q: expected %d; got %d", tc.accept, tc.exp, got)
+		}
+	}
+}
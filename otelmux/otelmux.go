@@ -0,0 +1,103 @@
+// Package otelmux adapts an OpenTelemetry TracerProvider to the mux.Tracer
+// interface.
+//
+// Usage:
+//
+//	m := mux.New(mux.WithTracer(otelmux.New(otel.GetTracerProvider())))
+package otelmux
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/josestg/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name of the created tracer.
+const ScopeName = "github.com/josestg/mux/otelmux"
+
+// AttributeStatusCode is the span attribute holding the response status code.
+const AttributeStatusCode = "http.response.status_code"
+
+// Tracer is a mux.Tracer backed by an OpenTelemetry tracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New creates a new Tracer from the given TracerProvider.
+func New(tp trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: tp.Tracer(ScopeName)}
+}
+
+// Start implements the mux.Tracer interface.
+func (t *Tracer) Start(ctx context.Context, name string, parent mux.SpanContext) (context.Context, mux.Span) {
+	if parent.IsValid() {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    parent.TraceID,
+			SpanID:     parent.SpanID,
+			TraceFlags: trace.TraceFlags(parent.Flags),
+			Remote:     true,
+		}))
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+	return ctx, &otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) SpanContext() mux.SpanContext {
+	sc := s.span.SpanContext()
+	return mux.SpanContext{
+		TraceID: sc.TraceID(),
+		SpanID:  sc.SpanID(),
+		Flags:   byte(sc.TraceFlags()),
+	}
+}
+
+func (s *otelSpan) SetAttributes(attrs ...mux.Attribute) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		kvs = append(kvs, keyValue(attr))
+	}
+	s.span.SetAttributes(kvs...)
+}
+
+func (s *otelSpan) SetStatus(code int) {
+	s.span.SetAttributes(attribute.Int(AttributeStatusCode, code))
+
+	// server spans are only marked as error for 5xx, a 4xx is caused by the
+	// client.
+	if code >= http.StatusInternalServerError {
+		s.span.SetStatus(codes.Error, http.StatusText(code))
+	}
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}
+
+func keyValue(attr mux.Attribute) attribute.KeyValue {
+	switch v := attr.Value.(type) {
+	case string:
+		return attribute.String(attr.Key, v)
+	case int:
+		return attribute.Int(attr.Key, v)
+	case int64:
+		return attribute.Int64(attr.Key, v)
+	case bool:
+		return attribute.Bool(attr.Key, v)
+	case float64:
+		return attribute.Float64(attr.Key, v)
+	case []string:
+		return attribute.StringSlice(attr.Key, v)
+	default:
+		return attribute.String(attr.Key, fmt.Sprint(v))
+	}
+}
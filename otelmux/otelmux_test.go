@@ -0,0 +1,56 @@
+package otelmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josestg/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	m := mux.New(mux.WithTracer(New(tp)))
+	m.HandleFunc(http.MethodGet, "/books/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	req := httptest.NewRequest(http.MethodGet, "/books/1", nil)
+	req.Header.Set(mux.TraceParentHeader, parent)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected %d spans; got %d", 1, len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "GET /books/:id" {
+		t.Fatalf("expected name %q; got %q", "GET /books/:id", span.Name)
+	}
+
+	if got := span.Parent.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected parent trace id; got %s", got)
+	}
+
+	if span.Status.Code != codes.Error {
+		t.Fatalf("expected status %v; got %v", codes.Error, span.Status.Code)
+	}
+
+	attrs := attribute.NewSet(span.Attributes...)
+	if v, _ := attrs.Value(mux.AttributeRoute); v.AsString() != "/books/:id" {
+		t.Fatalf("expected route attribute %q; got %q", "/books/:id", v.AsString())
+	}
+
+	if v, _ := attrs.Value(AttributeStatusCode); v.AsInt64() != http.StatusInternalServerError {
+		t.Fatalf("expected status code attribute %d; got %d", http.StatusInternalServerError, v.AsInt64())
+	}
+}
@@ -0,0 +1,131 @@
+package mux
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemContentType is the media type of Problem documents.
+const ProblemContentType = "application/problem+json"
+
+// Problem is a problem details document as defined by RFC 9457.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9457.
+type Problem struct {
+	// Type is a URI reference identifying the problem type. When empty,
+	// "about:blank" is assumed, meaning the problem has no semantics other
+	// than the status code.
+	Type string `json:"type,omitempty"`
+
+	// Title is a short summary of the problem type.
+	Title string `json:"title,omitempty"`
+
+	// Status is the HTTP status code.
+	Status int `json:"status,omitempty"`
+
+	// Detail is an explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is a URI reference identifying this occurrence of the
+	// problem.
+	Instance string `json:"instance,omitempty"`
+}
+
+// NewProblem creates an "about:blank" Problem for the given status code and
+// request.
+func NewProblem(r *http.Request, status int, detail string) Problem {
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	}
+}
+
+// WriteProblem writes p as an application/problem+json response.
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	JSONProblemRenderer{}.RenderProblem(w, p)
+}
+
+// ProblemRenderer writes a Problem in a specific format.
+type ProblemRenderer interface {
+	// MediaTypes returns the media types matched against the Accept header.
+	MediaTypes() []string
+
+	// RenderProblem writes p as the response.
+	RenderProblem(w http.ResponseWriter, p Problem)
+}
+
+// JSONProblemRenderer renders a Problem as application/problem+json. It is
+// chosen when the client accepts either application/problem+json or
+// application/json.
+type JSONProblemRenderer struct{}
+
+// MediaTypes implements the ProblemRenderer interface.
+func (JSONProblemRenderer) MediaTypes() []string {
+	return []string{ProblemContentType, "application/json"}
+}
+
+// RenderProblem implements the ProblemRenderer interface.
+func (JSONProblemRenderer) RenderProblem(w http.ResponseWriter, p Problem) {
+	h := w.Header()
+	h.Set("Content-Type", ProblemContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Del("Content-Length")
+
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// TextProblemRenderer renders a Problem as text/plain like http.Error.
+type TextProblemRenderer struct{}
+
+// MediaTypes implements the ProblemRenderer interface.
+func (TextProblemRenderer) MediaTypes() []string {
+	return []string{"text/plain"}
+}
+
+// RenderProblem implements the ProblemRenderer interface.
+func (TextProblemRenderer) RenderProblem(w http.ResponseWriter, p Problem) {
+	msg := p.Title
+	if p.Detail != "" {
+		msg += ": " + p.Detail
+	}
+	http.Error(w, msg, p.Status)
+}
+
+// RenderProblem writes p using the ProblemRenderer of the Mux negotiated
+// with the Accept header of r. When no renderer is acceptable, the first
+// renderer is used.
+func RenderProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	renderers := defaultProblemRenderers
+	if opts := getRequestInfo(r.Context()).options; opts != nil && len(opts.ProblemRenderers) > 0 {
+		renderers = opts.ProblemRenderers
+	}
+
+	var offers []string
+	var owners []ProblemRenderer
+	for _, pr := range renderers {
+		for _, mt := range pr.MediaTypes() {
+			offers = append(offers, mt)
+			owners = append(owners, pr)
+		}
+	}
+
+	pr := renderers[0]
+	if i := negotiate(r.Header.Get("Accept"), offers); i >= 0 {
+		pr = owners[i]
+	}
+
+	pr.RenderProblem(w, p)
+}
+
+// Error writes the "about:blank" Problem of the given status code with
+// RenderProblem. The router and the built-in middlewares use it for the
+// errors they generate.
+func Error(w http.ResponseWriter, r *http.Request, status int) {
+	RenderProblem(w, r, NewProblem(r, status, ""))
+}
+
+var defaultProblemRenderers = []ProblemRenderer{TextProblemRenderer{}, JSONProblemRenderer{}}
@@ -0,0 +1,89 @@
+package mux_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/josestg/mux"
+)
+
+type xmlProblemRenderer struct{}
+
+func (xmlProblemRenderer) MediaTypes() []string { return []string{"application/problem+xml"} }
+
+func (xmlProblemRenderer) RenderProblem(w http.ResponseWriter, p mux.Problem) {
+	w.Header().Set("Content-Type", "application/problem+xml")
+	w.WriteHeader(p.Status)
+	_ = xml.NewEncoder(w).Encode(p)
+}
+
+func TestMux_ProblemRendering(t *testing.T) {
+	handler := mux.New(mux.WithProblemRenderer(xmlProblemRenderer{}))
+	handler.Use(mux.RequestBody(4, "application/json"))
+	handler.Handle(http.MethodPost, "/books", fakeHandler(0))
+
+	tests := []struct {
+		method         string
+		path           string
+		contentType    string
+		body           string
+		accept         string
+		expStatusCode  int
+		expContentType string
+	}{
+		{method: http.MethodGet, path: "/unknown", accept: "", expStatusCode: 404, expContentType: "text/plain; charset=utf-8"},
+		{method: http.MethodGet, path: "/unknown", accept: "*/*", expStatusCode: 404, expContentType: "text/plain; charset=utf-8"},
+		{method: http.MethodGet, path: "/unknown", accept: "application/json", expStatusCode: 404, expContentType: mux.ProblemContentType},
+		{method: http.MethodGet, path: "/unknown", accept: "text/html,application/xhtml+xml,*/*;q=0.8", expStatusCode: 404, expContentType: "text/plain; charset=utf-8"},
+		{method: http.MethodGet, path: "/unknown", accept: "application/problem+xml, application/json;q=0.5", expStatusCode: 404, expContentType: "application/problem+xml"},
+		{method: http.MethodGet, path: "/unknown", accept: "image/png", expStatusCode: 404, expContentType: "text/plain; charset=utf-8"},
+		{method: http.MethodGet, path: "/books", accept: "application/json", expStatusCode: 405, expContentType: mux.ProblemContentType},
+		{method: http.MethodPost, path: "/books", contentType: "application/json", body: "[1, 2]", accept: "application/json", expStatusCode: 413, expContentType: mux.ProblemContentType},
+		{method: http.MethodPost, path: "/books", contentType: "text/csv", body: "1", accept: "application/json", expStatusCode: 415, expContentType: mux.ProblemContentType},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+		if tc.contentType != "" {
+			r.Header.Set("Content-Type", tc.contentType)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("case %d: expected %d; got %d", i, tc.expStatusCode, rec.Code)
+		}
+
+		if got := rec.Header().Get("Content-Type"); got != tc.expContentType {
+			t.Fatalf("case %d: expected content type %q; got %q", i, tc.expContentType, got)
+		}
+
+		if tc.expContentType != mux.ProblemContentType {
+			continue
+		}
+
+		var got mux.Problem
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+
+		exp := mux.Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(tc.expStatusCode),
+			Status:   tc.expStatusCode,
+			Instance: tc.path,
+		}
+		if !reflect.DeepEqual(got, exp) {
+			t.Fatalf("case %d: expected %+v; got %+v", i, exp, got)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/josestg/mux/internal/trie"
+)
+
+// ProxyOptions holds the optional fields of Proxy.
+type ProxyOptions struct {
+	// Rewrite is the template of the outgoing path, whose {name} are
+	// replaced by the Vars of the matched route, e.g. /v2/users/{id} for
+	// the route /api/users/:id. Empty keeps the incoming path.
+	Rewrite string
+
+	// Targets are the targets added to the one given to Proxy. The requests
+	// are balanced across them in round-robin.
+	Targets []*url.URL
+
+	// HealthCheckPath is the path requested with GET to check the health
+	// of the targets, every HealthCheckInterval. Zero interval disables
+	// health checks.
+	HealthCheckPath     string
+	HealthCheckInterval time.Duration
+
+	// PreserveHost keeps the Host header of the incoming request instead
+	// of the host of the target.
+	PreserveHost bool
+
+	// Transport is the http.RoundTripper of the outgoing requests and
+	// health checks, http.DefaultTransport when nil.
+	Transport http.RoundTripper
+
+	// Methods are the methods of the registered routes.
+	Methods []string
+
+	// RouteOptions are applied to the registered routes.
+	RouteOptions []RouteOptionApplier
+}
+
+// ProxyOptionApplier is a function for applying proxy option.
+type ProxyOptionApplier func(o *ProxyOptions)
+
+// WithRewrite is a proxy option applier setting the template of the
+// outgoing path.
+func WithRewrite(template string) ProxyOptionApplier {
+	return func(o *ProxyOptions) {
+		o.Rewrite = template
+	}
+}
+
+// WithTargets is a proxy option applier adding targets to balance the
+// requests across.
+func WithTargets(targets ...*url.URL) ProxyOptionApplier {
+	return func(o *ProxyOptions) {
+		o.Targets = append(o.Targets, targets...)
+	}
+}
+
+// WithHealthCheck is a proxy option applier enabling the health checks of
+// the targets.
+func WithHealthCheck(path string, interval time.Duration) ProxyOptionApplier {
+	return func(o *ProxyOptions) {
+		o.HealthCheckPath = path
+		o.HealthCheckInterval = interval
+	}
+}
+
+// WithPreserveHost is a proxy option applier keeping the incoming Host
+// header.
+func WithPreserveHost(enabled bool) ProxyOptionApplier {
+	return func(o *ProxyOptions) {
+		o.PreserveHost = enabled
+	}
+}
+
+// WithProxyTransport is a proxy option applier for the transport of the
+// outgoing requests.
+func WithProxyTransport(rt http.RoundTripper) ProxyOptionApplier {
+	return func(o *ProxyOptions) {
+		o.Transport = rt
+	}
+}
+
+// WithProxyMethods is a proxy option applier for the methods of the
+// registered routes. All the methods but CONNECT and TRACE are registered
+// by default.
+func WithProxyMethods(methods ...string) ProxyOptionApplier {
+	return func(o *ProxyOptions) {
+		o.Methods = methods
+	}
+}
+
+// WithProxyRouteOptions is a proxy option applier for the options of the
+// registered routes.
+func WithProxyRouteOptions(appliers ...RouteOptionApplier) ProxyOptionApplier {
+	return func(o *ProxyOptions) {
+		o.RouteOptions = append(o.RouteOptions, appliers...)
+	}
+}
+
+// Proxy registers routes forwarding the requests matching the pattern to
+// the target with an httputil.ReverseProxy, e.g. to move the endpoints of a
+// legacy service behind the Mux one at a time. Since a wildcard has the
+// lowest precedence, Proxy("/*path", legacy) forwards the requests whose path
+// is not matched by the other routes.
+//
+// The outgoing path is the path of the target joined with the incoming
+// path, or with the Rewrite template expanded with the Vars. The
+// X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto headers are set,
+// and with a Tracer, the traceparent header continues the span of the Mux.
+//
+// With health checks, the targets are checked after every interval. A
+// target failing its check or a request is skipped until its next
+// successful check, and 503 Service Unavailable is written when no target
+// is healthy. A failing request is answered with 502 Bad Gateway. The
+// returned Proxy must be closed to stop the health checks.
+//
+// It panics when the Rewrite template refers to a variable missing from
+// the pattern.
+func (m *Mux) Proxy(pattern string, target *url.URL, appliers ...ProxyOptionApplier) *Proxy {
+	opts := &ProxyOptions{
+		Methods: []string{
+			http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+			http.MethodPatch, http.MethodDelete, http.MethodOptions,
+		},
+	}
+
+	for _, apply := range appliers {
+		apply(opts)
+	}
+
+	if err := checkRewrite(pattern, opts.Rewrite); err != nil {
+		panic(err)
+	}
+
+	p := newProxy(append([]*url.URL{target}, opts.Targets...), opts)
+	for _, method := range opts.Methods {
+		m.Handle(method, pattern, p, opts.RouteOptions...)
+	}
+
+	return p
+}
+
+// Proxy is the http.Handler of the routes registered by Mux.Proxy.
+type Proxy struct {
+	opts    *ProxyOptions
+	rp      *httputil.ReverseProxy
+	targets []*proxyTarget
+	next    uint32
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type proxyTarget struct {
+	url     *url.URL
+	healthy int32
+}
+
+type proxyTargetKey struct{}
+
+func newProxy(targets []*url.URL, opts *ProxyOptions) *Proxy {
+	p := &Proxy{opts: opts, done: make(chan struct{})}
+	for _, u := range targets {
+		p.targets = append(p.targets, &proxyTarget{url: u, healthy: 1})
+	}
+
+	p.rp = &httputil.ReverseProxy{
+		Director:     p.direct,
+		Transport:    opts.Transport,
+		ErrorHandler: p.error,
+	}
+
+	if opts.HealthCheckInterval > 0 {
+		p.wg.Add(1)
+		go p.healthCheck()
+	}
+
+	return p
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t := p.pick()
+	if t == nil {
+		Error(w, r, http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), proxyTargetKey{}, t)
+	p.rp.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// Healthy returns the targets currently considered healthy.
+func (p *Proxy) Healthy() []*url.URL {
+	var healthy []*url.URL
+	for _, t := range p.targets {
+		if atomic.LoadInt32(&t.healthy) == 1 {
+			healthy = append(healthy, t.url)
+		}
+	}
+	return healthy
+}
+
+// Close stops the health checks and waits for them to end.
+func (p *Proxy) Close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+	p.wg.Wait()
+}
+
+// pick returns the next healthy target in round-robin, nil when there is
+// none.
+func (p *Proxy) pick() *proxyTarget {
+	n := uint32(len(p.targets))
+	start := atomic.AddUint32(&p.next, 1) - 1
+
+	for i := uint32(0); i < n; i++ {
+		t := p.targets[(start+i)%n]
+		if atomic.LoadInt32(&t.healthy) == 1 {
+			return t
+		}
+	}
+	return nil
+}
+
+func (p *Proxy) direct(out *http.Request) {
+	t := out.Context().Value(proxyTargetKey{}).(*proxyTarget)
+
+	proto := "http"
+	if out.TLS != nil {
+		proto = "https"
+	}
+	out.Header.Set("X-Forwarded-Host", out.Host)
+	out.Header.Set("X-Forwarded-Proto", proto)
+
+	// the backend continues the trace from the span of the Mux.
+	InjectTraceParent(out.Context(), out.Header)
+
+	outPath := out.URL.Path
+	if p.opts.Rewrite != "" {
+		outPath = expandRewrite(p.opts.Rewrite, GetVars(out.Context()))
+	}
+
+	out.URL.Scheme = t.url.Scheme
+	out.URL.Host = t.url.Host
+	out.URL.Path = joinURLPath(t.url.Path, outPath)
+	out.URL.RawPath = ""
+
+	switch {
+	case t.url.RawQuery == "":
+	case out.URL.RawQuery == "":
+		out.URL.RawQuery = t.url.RawQuery
+	default:
+		out.URL.RawQuery = t.url.RawQuery + "&" + out.URL.RawQuery
+	}
+
+	if !p.opts.PreserveHost {
+		out.Host = ""
+	}
+}
+
+func (p *Proxy) error(w http.ResponseWriter, r *http.Request, err error) {
+	if p.opts.HealthCheckInterval > 0 && !errors.Is(err, context.Canceled) {
+		t := r.Context().Value(proxyTargetKey{}).(*proxyTarget)
+		atomic.StoreInt32(&t.healthy, 0)
+	}
+
+	Error(w, r, http.StatusBadGateway)
+}
+
+func (p *Proxy) healthCheck() {
+	defer p.wg.Done()
+
+	client := &http.Client{
+		Transport: p.opts.Transport,
+		Timeout:   p.opts.HealthCheckInterval,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	ticker := time.NewTicker(p.opts.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+
+		for _, t := range p.targets {
+			healthy := int32(0)
+			if p.check(client, t) {
+				healthy = 1
+			}
+			atomic.StoreInt32(&t.healthy, healthy)
+		}
+	}
+}
+
+func (p *Proxy) check(client *http.Client, t *proxyTarget) bool {
+	u := *t.url
+	u.Path = joinURLPath(u.Path, p.opts.HealthCheckPath)
+	u.RawPath = ""
+
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return false
+	}
+	_ = resp.Body.Close()
+
+	return resp.StatusCode >= 200 && resp.StatusCode < 400
+}
+
+// checkRewrite returns an error when the template refers to a variable
+// missing from the pattern.
+func checkRewrite(pattern, template string) error {
+	names := make(map[string]bool)
+	for _, seg := range strings.Split(pattern, "/") {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			names[seg[1:]] = true
+		}
+	}
+
+	rest := template
+	for {
+		i := strings.IndexByte(rest, '{')
+		if i < 0 {
+			return nil
+		}
+
+		j := strings.IndexByte(rest[i:], '}')
+		if j < 0 {
+			return fmt.Errorf("unclosed variable in the rewrite. got=(%s)", template)
+		}
+
+		if name := rest[i+1 : i+j]; !names[name] {
+			return fmt.Errorf("rewrite variable is not in the pattern. want=(%s), got=(%s)", pattern, name)
+		}
+		rest = rest[i+j+1:]
+	}
+}
+
+// expandRewrite replaces the {name} of the template by the variables.
+func expandRewrite(template string, vars trie.Vars) string {
+	var b strings.Builder
+	rest := template
+	for {
+		i := strings.IndexByte(rest, '{')
+		if i < 0 {
+			b.WriteString(rest)
+			return b.String()
+		}
+
+		j := strings.IndexByte(rest[i:], '}')
+		b.WriteString(rest[:i])
+		b.WriteString(vars.Get(rest[i+1 : i+j]))
+		rest = rest[i+j+1:]
+	}
+}
+
+// joinURLPath joins the paths with a single slash between them.
+func joinURLPath(a, b string) string {
+	switch {
+	case a == "":
+		if !strings.HasPrefix(b, "/") {
+			return "/" + b
+		}
+		return b
+	case b == "":
+		return a
+	}
+
+	return strings.TrimSuffix(a, "/") + "/" + strings.TrimPrefix(b, "/")
+}
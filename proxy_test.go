@@ -0,0 +1,194 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/tracetest"
+)
+
+func newBackend(t *testing.T, name string) (*httptest.Server, *url.URL) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			if name == "sick" {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, "%s %s %s", name, r.Method, r.URL.RequestURI())
+	}))
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	return server, u
+}
+
+func TestMux_Proxy(t *testing.T) {
+	var got *http.Request
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		_, _ = fmt.Fprint(w, r.URL.RequestURI())
+	}))
+	t.Cleanup(backend.Close)
+
+	target, _ := url.Parse(backend.URL + "/base?v=1")
+	_, legacy := newBackend(t, "legacy")
+
+	handler := mux.New()
+	handler.HandleFunc(http.MethodGet, "/users/new", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "local")
+	})
+	handler.Proxy("/api/users/:id", target, mux.WithRewrite("/v2/users/{id}"))
+	handler.Proxy("/api/files/*path", target, mux.WithRewrite("/storage/{path}"), mux.WithPreserveHost(true))
+	handler.Proxy("/*path", legacy)
+
+	tests := []struct {
+		method  string
+		target  string
+		expBody string
+	}{
+		{method: http.MethodGet, target: "/api/users/42?page=2", expBody: "/base/v2/users/42?v=1&page=2"},
+		{method: http.MethodDelete, target: "/api/users/42", expBody: "/base/v2/users/42?v=1"},
+		{method: http.MethodGet, target: "/api/files/a/b%20c.txt", expBody: "/base/storage/a/b%20c.txt?v=1"},
+		{method: http.MethodGet, target: "/users/new", expBody: "local"},
+		{method: http.MethodPost, target: "/users", expBody: "legacy POST /users"},
+		{method: http.MethodGet, target: "/orders/1?all", expBody: "legacy GET /orders/1?all"},
+	}
+
+	for i, tc := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, nil))
+
+		if rec.Code != http.StatusOK || rec.Body.String() != tc.expBody {
+			t.Fatalf("%d: expected %q; got %d %q", i, tc.expBody, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/7", nil))
+
+	h := got.Header
+	if h.Get("X-Forwarded-Host") != "example.com" || h.Get("X-Forwarded-Proto") != "http" || h.Get("X-Forwarded-For") == "" {
+		t.Fatalf("expected X-Forwarded headers; got %v", h)
+	}
+
+	if got.Host != target.Host {
+		t.Fatalf("expected host %q; got %q", target.Host, got.Host)
+	}
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/files/x", nil))
+	if got.Host != "example.com" {
+		t.Fatalf("expected preserved host %q; got %q", "example.com", got.Host)
+	}
+}
+
+func TestMux_Proxy_RoundRobin(t *testing.T) {
+	_, a := newBackend(t, "a")
+	_, b := newBackend(t, "b")
+
+	handler := mux.New()
+	handler.Proxy("/books", a, mux.WithTargets(b))
+
+	var names []string
+	for i := 0; i < 4; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books", nil))
+		names = append(names, strings.Fields(rec.Body.String())[0])
+	}
+
+	if exp := "a,b,a,b"; strings.Join(names, ",") != exp {
+		t.Fatalf("expected targets %q; got %q", exp, strings.Join(names, ","))
+	}
+}
+
+func TestMux_Proxy_HealthCheck(t *testing.T) {
+	_, healthy := newBackend(t, "healthy")
+	_, sick := newBackend(t, "sick")
+	down, downURL := newBackend(t, "down")
+	down.Close()
+
+	handler := mux.New()
+	p := handler.Proxy("/books", healthy, mux.WithTargets(sick), mux.WithHealthCheck("/health", 10*time.Millisecond))
+	t.Cleanup(p.Close)
+
+	deadline := time.Now().Add(time.Second)
+	for len(p.Healthy()) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected only one healthy target; got %v", p.Healthy())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books", nil))
+		if !strings.HasPrefix(rec.Body.String(), "healthy") {
+			t.Fatalf("%d: expected the healthy target; got %q", i, rec.Body.String())
+		}
+	}
+
+	// the failing request marks the target down before its first check.
+	pd := handler.Proxy("/down", downURL, mux.WithHealthCheck("/health", time.Hour))
+	t.Cleanup(pd.Close)
+
+	for _, exp := range []int{http.StatusBadGateway, http.StatusServiceUnavailable} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/down", nil))
+		if rec.Code != exp {
+			t.Fatalf("expected status code %d; got %d", exp, rec.Code)
+		}
+	}
+}
+
+func TestMux_Proxy_TraceParent(t *testing.T) {
+	traceparent := make(chan string, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent <- r.Header.Get(mux.TraceParentHeader)
+	}))
+	t.Cleanup(backend.Close)
+
+	target, _ := url.Parse(backend.URL)
+
+	recorder := tracetest.NewRecorder()
+	handler := mux.New(mux.WithTracer(recorder))
+	handler.Proxy("/api/*path", target)
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	req := httptest.NewRequest(http.MethodGet, "/api/books", nil)
+	req.Header.Set(mux.TraceParentHeader, parent)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span; got %d", len(spans))
+	}
+
+	if got, exp := <-traceparent, spans[0].Context.TraceParent(); got != exp {
+		t.Fatalf("expected the backend to continue the span %q; got %q", exp, got)
+	}
+
+	if got := spans[0].Parent.TraceParent(); got != parent {
+		t.Fatalf("expected the span to continue %q; got %q", parent, got)
+	}
+}
+
+func TestMux_Proxy_InvalidRewrite(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+
+	target, _ := url.Parse("http://localhost")
+	mux.New().Proxy("/users/:id", target, mux.WithRewrite("/v2/users/{user}"))
+}
@@ -0,0 +1,185 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Rate is the number of requests allowed per period.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// PerSecond creates a Rate of n requests per second.
+func PerSecond(n int) Rate {
+	return Rate{Limit: n, Period: time.Second}
+}
+
+// PerMinute creates a Rate of n requests per minute.
+func PerMinute(n int) Rate {
+	return Rate{Limit: n, Period: time.Minute}
+}
+
+// PerHour creates a Rate of n requests per hour.
+func PerHour(n int) Rate {
+	return Rate{Limit: n, Period: time.Hour}
+}
+
+// check panics when the rate does not allow any request.
+func (r Rate) check() {
+	if r.Limit <= 0 || r.Period <= 0 {
+		panic(fmt.Errorf("rate limit and period must be positive. got=(%d per %s)", r.Limit, r.Period))
+	}
+}
+
+// State is the persisted state of a single key. Its fields are interpreted
+// by the Limiter which owns it.
+type State struct {
+	Count float64
+	Prev  float64
+	Stamp time.Time
+}
+
+// Result is the outcome of Limiter.Take.
+type Result struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+
+	// Limit is the maximum number of requests in the quota.
+	Limit int
+
+	// Remaining is the number of requests left in the quota.
+	Remaining int
+
+	// Reset is the time until the quota is fully restored.
+	Reset time.Duration
+
+	// RetryAfter is the time until the next request is allowed. It is
+	// zero when the request is allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter is a rate limiting algorithm. Implementations are stateless, the
+// state of each key is kept by a Store.
+type Limiter interface {
+	// Take tries to consume one request from s at the given time.
+	Take(s *State, now time.Time) Result
+
+	// TTL is how long the state of an idle key has to be kept.
+	TTL() time.Duration
+}
+
+type tokenBucket struct {
+	rate  Rate
+	burst int
+}
+
+// TokenBucket creates a token bucket Limiter. The bucket holds up to burst
+// tokens and is refilled at the given rate. A burst less than one uses the
+// rate limit as the bucket size. It panics when the limit or the period of
+// the rate is not positive.
+func TokenBucket(rate Rate, burst int) Limiter {
+	rate.check()
+	if burst < 1 {
+		burst = rate.Limit
+	}
+	return &tokenBucket{rate: rate, burst: burst}
+}
+
+func (tb *tokenBucket) perToken() time.Duration {
+	return tb.rate.Period / time.Duration(tb.rate.Limit)
+}
+
+func (tb *tokenBucket) Take(s *State, now time.Time) Result {
+	capacity := float64(tb.burst)
+	perToken := tb.perToken()
+
+	if s.Stamp.IsZero() {
+		s.Count = capacity
+	} else if elapsed := now.Sub(s.Stamp); elapsed > 0 {
+		s.Count = math.Min(capacity, s.Count+float64(elapsed)/float64(perToken))
+	}
+	s.Stamp = now
+
+	res := Result{Limit: tb.burst}
+	if s.Count >= 1 {
+		s.Count--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - s.Count) * float64(perToken))
+	}
+
+	res.Remaining = int(s.Count)
+	res.Reset = time.Duration((capacity - s.Count) * float64(perToken))
+	return res
+}
+
+func (tb *tokenBucket) TTL() time.Duration {
+	return time.Duration(tb.burst) * tb.perToken()
+}
+
+type slidingWindow struct {
+	rate Rate
+}
+
+// SlidingWindow creates a sliding window counter Limiter. The number of
+// requests in the sliding window is estimated from the counters of the
+// current and the previous fixed windows. It panics when the limit or the
+// period of the rate is not positive.
+func SlidingWindow(rate Rate) Limiter {
+	rate.check()
+	return &slidingWindow{rate: rate}
+}
+
+func (sw *slidingWindow) Take(s *State, now time.Time) Result {
+	window := sw.rate.Period
+	start := now.Truncate(window)
+
+	if !s.Stamp.Equal(start) {
+		if s.Stamp.Equal(start.Add(-window)) {
+			s.Prev = s.Count
+		} else {
+			s.Prev = 0
+		}
+		s.Count = 0
+		s.Stamp = start
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(window)
+	limit := float64(sw.rate.Limit)
+	estimated := s.Prev*weight + s.Count
+
+	res := Result{Limit: sw.rate.Limit, Reset: window - elapsed}
+	if estimated+1 <= limit {
+		s.Count++
+		estimated++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = sw.retryAfter(s, elapsed)
+	}
+
+	res.Remaining = int(math.Max(0, math.Floor(limit-estimated)))
+	return res
+}
+
+// retryAfter returns the time until the weight of the previous window has
+// dropped enough to allow one more request.
+func (sw *slidingWindow) retryAfter(s *State, elapsed time.Duration) time.Duration {
+	window := sw.rate.Period
+	limit := float64(sw.rate.Limit)
+
+	if s.Count+1 > limit || s.Prev == 0 {
+		return window - elapsed
+	}
+
+	// solve Prev*(1-t/window) + Count + 1 <= limit for t.
+	t := float64(window) * (1 - (limit-1-s.Count)/s.Prev)
+	return time.Duration(math.Ceil(t)) - elapsed
+}
+
+func (sw *slidingWindow) TTL() time.Duration {
+	return 2 * sw.rate.Period
+}
@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux/clock"
+)
+
+func TestTokenBucket(t *testing.T) {
+	lim := TokenBucket(PerSecond(2), 3)
+	now := time.Unix(1000, 0)
+
+	var s State
+	steps := []struct {
+		advance       time.Duration
+		expAllowed    bool
+		expRemaining  int
+		expRetryAfter time.Duration
+	}{
+		{advance: 0, expAllowed: true, expRemaining: 2},
+		{advance: 0, expAllowed: true, expRemaining: 1},
+		{advance: 0, expAllowed: true, expRemaining: 0},
+		{advance: 0, expAllowed: false, expRemaining: 0, expRetryAfter: 500 * time.Millisecond},
+		{advance: 250 * time.Millisecond, expAllowed: false, expRemaining: 0, expRetryAfter: 250 * time.Millisecond},
+		{advance: 250 * time.Millisecond, expAllowed: true, expRemaining: 0},
+		{advance: 10 * time.Second, expAllowed: true, expRemaining: 2},
+	}
+
+	for i, st := range steps {
+		now = now.Add(st.advance)
+		res := lim.Take(&s, now)
+
+		if res.Allowed != st.expAllowed {
+			t.Fatalf("step %d: expected allowed %v; got %v", i, st.expAllowed, res.Allowed)
+		}
+
+		if res.Remaining != st.expRemaining {
+			t.Fatalf("step %d: expected remaining %d; got %d", i, st.expRemaining, res.Remaining)
+		}
+
+		if res.RetryAfter != st.expRetryAfter {
+			t.Fatalf("step %d: expected retry after %v; got %v", i, st.expRetryAfter, res.RetryAfter)
+		}
+
+		if res.Limit != 3 {
+			t.Fatalf("step %d: expected limit %d; got %d", i, 3, res.Limit)
+		}
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	lim := SlidingWindow(PerMinute(4))
+	now := time.Unix(6000, 0) // aligned to a minute.
+
+	var s State
+	steps := []struct {
+		advance       time.Duration
+		expAllowed    bool
+		expRemaining  int
+		expRetryAfter time.Duration
+	}{
+		{advance: 0, expAllowed: true, expRemaining: 3},
+		{advance: 10 * time.Second, expAllowed: true, expRemaining: 2},
+		{advance: 10 * time.Second, expAllowed: true, expRemaining: 1},
+		{advance: 10 * time.Second, expAllowed: true, expRemaining: 0},
+		{advance: 10 * time.Second, expAllowed: false, expRemaining: 0, expRetryAfter: 20 * time.Second},
+		// next window, the previous window weighs 3/4: 4*0.75 = 3.
+		{advance: 35 * time.Second, expAllowed: true, expRemaining: 0},
+		// 4*0.75 + 1 = 4, one more needs the weight to drop to 2/4.
+		{advance: 0, expAllowed: false, expRemaining: 0, expRetryAfter: 15 * time.Second},
+		{advance: 15 * time.Second, expAllowed: true, expRemaining: 0},
+		// two windows later, the state is reset.
+		{advance: 2 * time.Minute, expAllowed: true, expRemaining: 3},
+	}
+
+	for i, st := range steps {
+		now = now.Add(st.advance)
+		res := lim.Take(&s, now)
+
+		if res.Allowed != st.expAllowed {
+			t.Fatalf("step %d: expected allowed %v; got %v", i, st.expAllowed, res.Allowed)
+		}
+
+		if res.Remaining != st.expRemaining {
+			t.Fatalf("step %d: expected remaining %d; got %d", i, st.expRemaining, res.Remaining)
+		}
+
+		if res.RetryAfter != st.expRetryAfter {
+			t.Fatalf("step %d: expected retry after %v; got %v", i, st.expRetryAfter, res.RetryAfter)
+		}
+	}
+}
+
+func TestLimiter_InvalidRate(t *testing.T) {
+	tests := []func(){
+		func() { TokenBucket(Rate{Limit: 0, Period: time.Second}, 5) },
+		func() { TokenBucket(Rate{Limit: 5, Period: 0}, 5) },
+		func() { SlidingWindow(Rate{Limit: -1, Period: time.Second}) },
+		func() { SlidingWindow(Rate{Limit: 5, Period: -time.Second}) },
+	}
+
+	for i, create := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%d: expected panic", i)
+				}
+			}()
+
+			create()
+		}()
+	}
+}
+
+func TestMemoryStore_Expire(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	store := NewMemoryStore(clk)
+
+	incr := func(s *State) { s.Count++ }
+	for i := 0; i < 3; i++ {
+		_ = store.Update(context.Background(), "a", time.Second, incr)
+	}
+
+	var got State
+	_ = store.Update(context.Background(), "a", time.Second, func(s *State) { got = *s })
+	if got.Count != 3 {
+		t.Fatalf("expected count %v; got %v", 3, got.Count)
+	}
+
+	clk.Advance(time.Second)
+	_ = store.Update(context.Background(), "a", time.Second, func(s *State) { got = *s })
+	if got.Count != 0 {
+		t.Fatalf("expected expired state; got %+v", got)
+	}
+}
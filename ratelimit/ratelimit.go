@@ -0,0 +1,191 @@
+// Package ratelimit provides a Mux middleware which limits the request rate
+// of every client per route pattern, prefixed by the version of the route,
+// e.g. /v2/books/:id.
+//
+// The quota of a client is reported with the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers, and requests above the
+// quota are answered with 429 Too Many Requests and a Retry-After header.
+//
+// See: https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers.
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/clock"
+)
+
+// Rate limit response headers.
+const (
+	HeaderLimit      = "RateLimit-Limit"
+	HeaderRemaining  = "RateLimit-Remaining"
+	HeaderReset      = "RateLimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+)
+
+// KeyFunc identifies the client of a request.
+type KeyFunc func(r *http.Request) string
+
+// ClientIP identifies the client by the IP address of the connection.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Header identifies the client by the value of the given header, e.g. an
+// API key. Requests without the header are identified by ClientIP.
+func Header(name string) KeyFunc {
+	return func(r *http.Request) string {
+		if v := r.Header.Get(name); v != "" {
+			return v
+		}
+		return ClientIP(r)
+	}
+}
+
+// OptionApplier is a function for applying option.
+type OptionApplier func(o *Options)
+
+// Options holds the rate limit middleware optional fields.
+type Options struct {
+	// KeyFunc identifies the client of a request.
+	KeyFunc KeyFunc
+
+	// Store keeps the limiter states. When nil, a MemoryStore is used.
+	Store Store
+
+	// Clock tells the current time.
+	Clock clock.Clock
+
+	// Routes overrides the Limiter of the given route patterns. A pattern
+	// prefixed by a version, e.g. /v2/books/:id, takes precedence over the
+	// same pattern without it. A nil Limiter exempts the route from rate
+	// limiting.
+	Routes map[string]Limiter
+
+	// LimitedHandler writes the response of requests above the quota.
+	LimitedHandler http.Handler
+}
+
+// Default is a default option applier.
+func Default() OptionApplier {
+	return func(o *Options) {
+		o.KeyFunc = ClientIP
+		o.Store = nil
+		o.Clock = clock.System{}
+		o.Routes = make(map[string]Limiter)
+		o.LimitedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mux.Error(w, r, http.StatusTooManyRequests)
+		})
+	}
+}
+
+// WithKeyFunc sets the KeyFunc.
+func WithKeyFunc(fn KeyFunc) OptionApplier {
+	return func(o *Options) {
+		o.KeyFunc = fn
+	}
+}
+
+// WithStore sets the Store.
+func WithStore(s Store) OptionApplier {
+	return func(o *Options) {
+		o.Store = s
+	}
+}
+
+// WithClock sets the Clock.
+func WithClock(c clock.Clock) OptionApplier {
+	return func(o *Options) {
+		o.Clock = c
+	}
+}
+
+// WithRoute overrides the Limiter of the given route pattern, e.g.
+// /books/:id, or /v2/books/:id for the route of a version only. A nil
+// Limiter exempts the route from rate limiting.
+func WithRoute(pattern string, l Limiter) OptionApplier {
+	return func(o *Options) {
+		o.Routes[pattern] = l
+	}
+}
+
+// New creates a new rate limit middleware with Default option. Every route
+// pattern has its own quota per client, limited by the given Limiter unless
+// overridden by WithRoute. Requests that do not match any route are not
+// limited.
+//
+// When the Store fails, the request is let through.
+func New(limiter Limiter, appliers ...OptionApplier) mux.MiddlewareFunc {
+	var options Options
+	Default()(&options)
+
+	for _, apply := range appliers {
+		apply(&options)
+	}
+
+	if options.Store == nil {
+		options.Store = NewMemoryStore(options.Clock)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rt := mux.GetRoute(r.Context())
+			if rt == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			lim := limiter
+			if l, ok := options.Routes[rt.VersionedPattern()]; ok {
+				lim = l
+			} else if l, ok := options.Routes[rt.Pattern]; ok {
+				lim = l
+			}
+
+			if lim == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var res Result
+			now := options.Clock.Now()
+			key := rt.VersionedPattern() + "\x00" + options.KeyFunc(r)
+			err := options.Store.Update(r.Context(), key, lim.TTL(), func(s *State) {
+				res = lim.Take(s, now)
+			})
+
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set(HeaderLimit, strconv.Itoa(res.Limit))
+			h.Set(HeaderRemaining, strconv.Itoa(res.Remaining))
+			h.Set(HeaderReset, seconds(res.Reset))
+
+			if !res.Allowed {
+				h.Set(HeaderRetryAfter, seconds(res.RetryAfter))
+				options.LimitedHandler.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// seconds formats d as delta-seconds, rounded up so clients never retry
+// too early.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,134 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/clock"
+	"github.com/josestg/mux/ratelimit"
+)
+
+func TestMiddleware(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+
+	m := mux.New()
+	m.Use(ratelimit.New(
+		ratelimit.TokenBucket(ratelimit.PerMinute(2), 0),
+		ratelimit.WithClock(clk),
+		ratelimit.WithKeyFunc(ratelimit.Header("X-API-Key")),
+		ratelimit.WithRoute("/health", nil),
+	))
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	m.HandleFunc(http.MethodGet, "/books/:id", ok)
+	m.HandleFunc(http.MethodGet, "/authors", ok)
+	m.HandleFunc(http.MethodGet, "/health", ok)
+
+	do := func(path, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+		return rec
+	}
+
+	tests := []struct {
+		path          string
+		key           string
+		expStatusCode int
+		expRemaining  string
+		expRetry      string
+	}{
+		// the quota is shared by every path of the same route pattern.
+		{path: "/books/1", key: "a", expStatusCode: http.StatusOK, expRemaining: "1"},
+		{path: "/books/2", key: "a", expStatusCode: http.StatusOK, expRemaining: "0"},
+		{path: "/books/3", key: "a", expStatusCode: http.StatusTooManyRequests, expRemaining: "0", expRetry: "30"},
+		// other clients and other routes have their own quota.
+		{path: "/books/1", key: "b", expStatusCode: http.StatusOK, expRemaining: "1"},
+		{path: "/authors", key: "a", expStatusCode: http.StatusOK, expRemaining: "1"},
+	}
+
+	for i, tc := range tests {
+		rec := do(tc.path, tc.key)
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("case %d: expected status %d; got %d", i, tc.expStatusCode, rec.Code)
+		}
+
+		h := rec.Header()
+		if got := h.Get(ratelimit.HeaderLimit); got != "2" {
+			t.Fatalf("case %d: expected limit %q; got %q", i, "2", got)
+		}
+
+		if got := h.Get(ratelimit.HeaderRemaining); got != tc.expRemaining {
+			t.Fatalf("case %d: expected remaining %q; got %q", i, tc.expRemaining, got)
+		}
+
+		if got := h.Get(ratelimit.HeaderRetryAfter); got != tc.expRetry {
+			t.Fatalf("case %d: expected retry after %q; got %q", i, tc.expRetry, got)
+		}
+	}
+
+	clk.Advance(30 * time.Second)
+	if rec := do("/books/1", "a"); rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d after refill; got %d", http.StatusOK, rec.Code)
+	}
+
+	for i := 0; i < 5; i++ {
+		rec := do("/health", "a")
+		if rec.Code != http.StatusOK || rec.Header().Get(ratelimit.HeaderLimit) != "" {
+			t.Fatalf("expected exempted route to be unlimited")
+		}
+	}
+
+	if rec := do("/unknown", "a"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d; got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestMiddleware_Versions(t *testing.T) {
+	m := mux.New()
+	m.Use(ratelimit.New(
+		ratelimit.TokenBucket(ratelimit.PerMinute(1), 0),
+		ratelimit.WithClock(clock.NewFake(time.Unix(0, 0))),
+		ratelimit.WithRoute("/users/:id", ratelimit.TokenBucket(ratelimit.PerMinute(2), 0)),
+		ratelimit.WithRoute("/v2/users/:id", ratelimit.TokenBucket(ratelimit.PerMinute(3), 0)),
+	))
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	m.Version("v1").HandleFunc(http.MethodGet, "/users/:id", ok)
+	m.Version("v2").HandleFunc(http.MethodGet, "/users/:id", ok)
+
+	tests := []struct {
+		path         string
+		expLimit     string
+		expRemaining string
+	}{
+		// every version has its own quota, and its own limiter when one is
+		// registered for its prefixed pattern.
+		{path: "/v1/users/1", expLimit: "2", expRemaining: "1"},
+		{path: "/v2/users/1", expLimit: "3", expRemaining: "2"},
+		{path: "/v1/users/1", expLimit: "2", expRemaining: "0"},
+		{path: "/v2/users/1", expLimit: "3", expRemaining: "1"},
+	}
+
+	for i, tc := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("case %d: expected status %d; got %d", i, http.StatusOK, rec.Code)
+		}
+
+		if got := rec.Header().Get(ratelimit.HeaderLimit); got != tc.expLimit {
+			t.Fatalf("case %d: expected limit %q; got %q", i, tc.expLimit, got)
+		}
+
+		if got := rec.Header().Get(ratelimit.HeaderRemaining); got != tc.expRemaining {
+			t.Fatalf("case %d: expected remaining %q; got %q", i, tc.expRemaining, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/josestg/mux/clock"
+)
+
+// Store persists the State of every key.
+type Store interface {
+	// Update atomically loads the state of the key, passes it to fn and
+	// saves it back. The state of a new or expired key is the zero State.
+	// The saved state expires after ttl.
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(s *State)) error
+}
+
+// sweepEvery is the number of updates between two sweeps of expired keys.
+const sweepEvery = 1024
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	clock clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	updates int
+}
+
+type entry struct {
+	state   State
+	expires time.Time
+}
+
+// NewMemoryStore creates a new MemoryStore. The clock decides when keys
+// expire, a nil clock uses the clock.System.
+func NewMemoryStore(c clock.Clock) *MemoryStore {
+	if c == nil {
+		c = clock.System{}
+	}
+
+	return &MemoryStore{
+		clock:   c,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Update implements the Store interface.
+func (ms *MemoryStore) Update(_ context.Context, key string, ttl time.Duration, fn func(s *State)) error {
+	now := ms.clock.Now()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.updates++
+	if ms.updates%sweepEvery == 0 {
+		ms.sweep(now)
+	}
+
+	e, ok := ms.entries[key]
+	if !ok || !now.Before(e.expires) {
+		e = &entry{}
+		ms.entries[key] = e
+	}
+
+	fn(&e.state)
+	e.expires = now.Add(ttl)
+	return nil
+}
+
+// Len returns the number of stored keys, including the expired keys that
+// have not been swept yet.
+func (ms *MemoryStore) Len() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return len(ms.entries)
+}
+
+func (ms *MemoryStore) sweep(now time.Time) {
+	for k, e := range ms.entries {
+		if !now.Before(e.expires) {
+			delete(ms.entries, k)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package mux
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/josestg/mux/internal/codec"
+)
+
+// ErrNotAcceptable is returned by Render when none of the encoders produces
+// a content type accepted by the client.
+var ErrNotAcceptable = errors.New("mux: not acceptable")
+
+// Encoder encodes response values of a specific content type.
+type Encoder interface {
+	// ContentType returns the content type of the encoded values. Its media
+	// type is matched against the Accept header.
+	ContentType() string
+
+	// Encode writes the encoding of v to w.
+	Encode(w io.Writer, v any) error
+}
+
+// JSONEncoder encodes values as application/json.
+type JSONEncoder struct{}
+
+// ContentType implements the Encoder interface.
+func (JSONEncoder) ContentType() string { return "application/json" }
+
+// Encode implements the Encoder interface.
+func (JSONEncoder) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// XMLEncoder encodes values as application/xml. Since a document has a
+// single root element, slices and arrays are wrapped in an <items> element.
+// Maps are not supported by encoding/xml and fail to encode.
+type XMLEncoder struct{}
+
+// ContentType implements the Encoder interface.
+func (XMLEncoder) ContentType() string { return "application/xml" }
+
+// Encode implements the Encoder interface.
+func (XMLEncoder) Encode(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	if !isList(v) {
+		return enc.Encode(v)
+	}
+
+	root := xml.StartElement{Name: xml.Name{Local: "items"}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// isList reports whether v is a slice or an array, other than a byte slice
+// which is encoded as character data.
+func isList(v any) bool {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Array:
+		return true
+	case reflect.Slice:
+		return rv.Type().Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// MessagePackEncoder encodes values as application/msgpack.
+type MessagePackEncoder struct{}
+
+// ContentType implements the Encoder interface.
+func (MessagePackEncoder) ContentType() string { return "application/msgpack" }
+
+// Encode implements the Encoder interface.
+func (MessagePackEncoder) Encode(w io.Writer, v any) error {
+	b, err := codec.MarshalMsgPack(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// CBOREncoder encodes values as application/cbor.
+type CBOREncoder struct{}
+
+// ContentType implements the Encoder interface.
+func (CBOREncoder) ContentType() string { return "application/cbor" }
+
+// Encode implements the Encoder interface.
+func (CBOREncoder) Encode(w io.Writer, v any) error {
+	b, err := codec.MarshalCBOR(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// TextEncoder encodes values as text/plain using their default format.
+type TextEncoder struct{}
+
+// ContentType implements the Encoder interface.
+func (TextEncoder) ContentType() string { return "text/plain; charset=utf-8" }
+
+// Encode implements the Encoder interface.
+func (TextEncoder) Encode(w io.Writer, v any) error {
+	var err error
+	switch v := v.(type) {
+	case []byte:
+		_, err = w.Write(v)
+	default:
+		_, err = fmt.Fprint(w, v)
+	}
+	return err
+}
+
+// Render writes v with the given status code using the Encoder of the Mux
+// negotiated with the Accept header of r. When no encoder is acceptable,
+// 406 Not Acceptable is written and ErrNotAcceptable is returned. When the
+// encoding fails, nothing is written and the error is returned.
+func Render(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	encoders := defaultEncoders
+	if opts := getRequestInfo(r.Context()).options; opts != nil && len(opts.Encoders) > 0 {
+		encoders = opts.Encoders
+	}
+
+	offers := make([]string, len(encoders))
+	for i, enc := range encoders {
+		offers[i] = enc.ContentType()
+	}
+
+	i := negotiate(r.Header.Get("Accept"), offers)
+	if i < 0 {
+		Error(w, r, http.StatusNotAcceptable)
+		return ErrNotAcceptable
+	}
+
+	var buf bytes.Buffer
+	if err := encoders[i].Encode(&buf, v); err != nil {
+		return err
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", offers[i])
+	h.Add("Vary", "Accept")
+
+	w.WriteHeader(status)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+var defaultEncoders = []Encoder{JSONEncoder{}, XMLEncoder{}, MessagePackEncoder{}, CBOREncoder{}, TextEncoder{}}
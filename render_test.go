@@ -0,0 +1,167 @@
+package mux_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josestg/mux"
+)
+
+type book struct {
+	ID    int    `json:"id" xml:"id"`
+	Title string `json:"title" xml:"title"`
+}
+
+type csvEncoder struct{}
+
+func (csvEncoder) ContentType() string { return "text/csv" }
+
+func (csvEncoder) Encode(w io.Writer, v any) error {
+	b := v.(book)
+	_, err := io.WriteString(w, "1,"+b.Title+"\n")
+	return err
+}
+
+func TestRender(t *testing.T) {
+	var renderErr error
+	handler := mux.New()
+	handler.HandleFunc(http.MethodGet, "/books/:id", func(w http.ResponseWriter, r *http.Request) {
+		renderErr = mux.Render(w, r, http.StatusOK, book{ID: 1, Title: "Go"})
+	})
+
+	tests := []struct {
+		accept         string
+		expStatusCode  int
+		expContentType string
+		expBody        string
+		expErr         error
+	}{
+		{accept: "", expStatusCode: 200, expContentType: "application/json", expBody: `{"id":1,"title":"Go"}` + "\n"},
+		{accept: "*/*", expStatusCode: 200, expContentType: "application/json", expBody: `{"id":1,"title":"Go"}` + "\n"},
+		{accept: "application/xml", expStatusCode: 200, expContentType: "application/xml", expBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<book><id>1</id><title>Go</title></book>`},
+		{accept: "application/msgpack", expStatusCode: 200, expContentType: "application/msgpack", expBody: string(mustHex("82a2696401a57469746c65a2476f"))},
+		{accept: "application/cbor", expStatusCode: 200, expContentType: "application/cbor", expBody: string(mustHex("a262696401657469746c6562476f"))},
+		{accept: "text/*", expStatusCode: 200, expContentType: "text/plain; charset=utf-8", expBody: "{1 Go}"},
+		{accept: "application/json;q=0.5, application/xml", expStatusCode: 200, expContentType: "application/xml", expBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<book><id>1</id><title>Go</title></book>`},
+		{accept: "image/png", expStatusCode: 406, expContentType: "text/plain; charset=utf-8", expBody: "Not Acceptable\n", expErr: mux.ErrNotAcceptable},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/books/1", nil)
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("%d: expected status code %d; got %d", i, tc.expStatusCode, rec.Code)
+		}
+
+		if got := rec.Header().Get("Content-Type"); got != tc.expContentType {
+			t.Fatalf("%d: expected content type %q; got %q", i, tc.expContentType, got)
+		}
+
+		if got := rec.Body.String(); got != tc.expBody {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.expBody, got)
+		}
+
+		if !errors.Is(renderErr, tc.expErr) {
+			t.Fatalf("%d: expected error %v; got %v", i, tc.expErr, renderErr)
+		}
+	}
+}
+
+func TestRender_WithEncoders(t *testing.T) {
+	handler := mux.New(mux.WithEncoders(csvEncoder{}, mux.JSONEncoder{}))
+	handler.HandleFunc(http.MethodGet, "/books", func(w http.ResponseWriter, r *http.Request) {
+		_ = mux.Render(w, r, http.StatusOK, book{ID: 1, Title: "Go"})
+	})
+
+	tests := []struct {
+		accept         string
+		expStatusCode  int
+		expContentType string
+	}{
+		{accept: "", expStatusCode: 200, expContentType: "text/csv"},
+		{accept: "application/json", expStatusCode: 200, expContentType: "application/json"},
+		{accept: "application/xml", expStatusCode: 406, expContentType: "text/plain; charset=utf-8"},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/books", nil)
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("%d: expected status code %d; got %d", i, tc.expStatusCode, rec.Code)
+		}
+
+		if got := rec.Header().Get("Content-Type"); got != tc.expContentType {
+			t.Fatalf("%d: expected content type %q; got %q", i, tc.expContentType, got)
+		}
+	}
+}
+
+func TestXMLEncoder(t *testing.T) {
+	tests := []struct {
+		value   any
+		expBody string
+		expErr  bool
+	}{
+		{value: book{ID: 1, Title: "Go"}, expBody: `<book><id>1</id><title>Go</title></book>`},
+		{value: []book{{ID: 1, Title: "Go"}, {ID: 2, Title: "Rust"}}, expBody: `<items><book><id>1</id><title>Go</title></book><book><id>2</id><title>Rust</title></book></items>`},
+		{value: &[]book{{ID: 1, Title: "Go"}}, expBody: `<items><book><id>1</id><title>Go</title></book></items>`},
+		{value: [2]int{1, 2}, expBody: `<items><int>1</int><int>2</int></items>`},
+		{value: []book{}, expBody: `<items></items>`},
+		{value: map[string]int{"a": 1}, expErr: true},
+	}
+
+	for i, tc := range tests {
+		var buf bytes.Buffer
+		err := mux.XMLEncoder{}.Encode(&buf, tc.value)
+		if (err != nil) != tc.expErr {
+			t.Fatalf("%d: expected error %v; got %v", i, tc.expErr, err)
+		}
+
+		if tc.expErr {
+			continue
+		}
+
+		if exp := xml.Header + tc.expBody; buf.String() != exp {
+			t.Fatalf("%d: expected body %q; got %q", i, exp, buf.String())
+		}
+	}
+}
+
+func TestRender_EncodeError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	if err := mux.Render(rec, r, http.StatusOK, make(chan int)); err == nil {
+		t.Fatalf("expected error; got nil")
+	}
+
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "" {
+		t.Fatalf("expected nothing written; got %q", rec.Body.String())
+	}
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
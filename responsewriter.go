@@ -0,0 +1,341 @@
+package mux
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter is an http.ResponseWriter recording the response written
+// through it. It is returned by NewResponseWriter.
+type ResponseWriter interface {
+	http.ResponseWriter
+
+	// Status returns the status code of the response, http.StatusOK when
+	// nothing has been written yet.
+	Status() int
+
+	// BytesWritten returns the number of bytes of the body written so far.
+	BytesWritten() int64
+
+	// Written reports whether the response header has been written.
+	Written() bool
+
+	// Unwrap returns the wrapped http.ResponseWriter, which allows
+	// http.ResponseController to reach the underlying writer.
+	Unwrap() http.ResponseWriter
+}
+
+// NewResponseWriter wraps w into a ResponseWriter. The returned writer
+// implements http.Flusher, http.Hijacker, io.ReaderFrom and http.Pusher only
+// when w does, so the type assertions made by the handlers keep their
+// meaning through any number of middlewares.
+//
+// Informational 1xx status codes are passed through without writing the
+// response header, and a hijacked connection is reported as 101 Switching
+// Protocols when nothing was written before.
+func NewResponseWriter(w http.ResponseWriter) ResponseWriter {
+	return newResponseWriter(w, nil)
+}
+
+// ResponseWriterHooks replaces the calls made on a ResponseWriter returned
+// by HookResponseWriter, e.g. to buffer or re-encode the response. A nil hook
+// calls the wrapped writer.
+type ResponseWriterHooks struct {
+	// Header returns the header map written with the response.
+	Header func() http.Header
+
+	// WriteHeader is called for every status code, including the
+	// informational 1xx ones.
+	WriteHeader func(code int)
+
+	// Write is called with the body, after the status code has been
+	// recorded.
+	Write func(b []byte) (int, error)
+
+	// Flush is only called when the wrapped writer implements
+	// http.Flusher.
+	Flush func()
+
+	// Hijack is only called when the wrapped writer implements
+	// http.Hijacker.
+	Hijack func() (net.Conn, *bufio.ReadWriter, error)
+}
+
+// HookResponseWriter is like NewResponseWriter, with the calls replaced by
+// the hooks. The returned writer keeps implementing the optional interfaces
+// of w, except io.ReaderFrom when Write is hooked, so the handlers behind a
+// middleware changing the response can still flush or hijack it.
+func HookResponseWriter(w http.ResponseWriter, hooks ResponseWriterHooks) ResponseWriter {
+	return wrapResponseWriter(&responseWriter{w: w, hooks: hooks})
+}
+
+// newResponseWriter is like NewResponseWriter, with replace called before
+// the final response header is written. When replace writes another
+// response to w, it returns its status code and the response of the
+// handler is discarded.
+func newResponseWriter(w http.ResponseWriter, replace func(code int) int) ResponseWriter {
+	return wrapResponseWriter(&responseWriter{w: w, replace: replace})
+}
+
+// wrapResponseWriter adds the optional interfaces of the wrapped writer to
+// rw.
+func wrapResponseWriter(rw *responseWriter) ResponseWriter {
+	w := rw.w
+
+	const (
+		flusher = 1 << iota
+		hijacker
+		readerFrom
+		pusher
+	)
+
+	var kind int
+	if _, ok := w.(http.Flusher); ok {
+		kind |= flusher
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		kind |= hijacker
+	}
+	if _, ok := w.(io.ReaderFrom); ok && rw.hooks.Write == nil {
+		kind |= readerFrom
+	}
+	if _, ok := w.(http.Pusher); ok {
+		kind |= pusher
+	}
+
+	f, h, rf, p := rwFlusher{rw}, rwHijacker{rw}, rwReaderFrom{rw}, rwPusher{rw}
+
+	switch kind {
+	case flusher:
+		return struct {
+			*responseWriter
+			http.Flusher
+		}{rw, f}
+	case hijacker:
+		return struct {
+			*responseWriter
+			http.Hijacker
+		}{rw, h}
+	case flusher | hijacker:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+		}{rw, f, h}
+	case readerFrom:
+		return struct {
+			*responseWriter
+			io.ReaderFrom
+		}{rw, rf}
+	case flusher | readerFrom:
+		return struct {
+			*responseWriter
+			http.Flusher
+			io.ReaderFrom
+		}{rw, f, rf}
+	case hijacker | readerFrom:
+		return struct {
+			*responseWriter
+			http.Hijacker
+			io.ReaderFrom
+		}{rw, h, rf}
+	case flusher | hijacker | readerFrom:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{rw, f, h, rf}
+	case pusher:
+		return struct {
+			*responseWriter
+			http.Pusher
+		}{rw, p}
+	case flusher | pusher:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Pusher
+		}{rw, f, p}
+	case hijacker | pusher:
+		return struct {
+			*responseWriter
+			http.Hijacker
+			http.Pusher
+		}{rw, h, p}
+	case flusher | hijacker | pusher:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{rw, f, h, p}
+	case readerFrom | pusher:
+		return struct {
+			*responseWriter
+			io.ReaderFrom
+			http.Pusher
+		}{rw, rf, p}
+	case flusher | readerFrom | pusher:
+		return struct {
+			*responseWriter
+			http.Flusher
+			io.ReaderFrom
+			http.Pusher
+		}{rw, f, rf, p}
+	case hijacker | readerFrom | pusher:
+		return struct {
+			*responseWriter
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{rw, h, rf, p}
+	case flusher | hijacker | readerFrom | pusher:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{rw, f, h, rf, p}
+	default:
+		return rw
+	}
+}
+
+// responseWriter implements the ResponseWriter without the optional
+// interfaces, which are added by NewResponseWriter.
+type responseWriter struct {
+	w       http.ResponseWriter
+	code    int
+	written int64
+
+	replace  func(code int) int
+	replaced bool
+	hooks    ResponseWriterHooks
+}
+
+func (rw *responseWriter) Header() http.Header {
+	if rw.hooks.Header != nil {
+		return rw.hooks.Header()
+	}
+	return rw.w.Header()
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.replaced {
+		return
+	}
+
+	if rw.code == 0 && (code >= http.StatusOK || code == http.StatusSwitchingProtocols) {
+		if rw.replace != nil {
+			if replaced := rw.replace(code); replaced != 0 {
+				rw.code, rw.replaced = replaced, true
+				return
+			}
+		}
+		rw.code = code
+	}
+
+	if rw.hooks.WriteHeader != nil {
+		rw.hooks.WriteHeader(code)
+		return
+	}
+	rw.w.WriteHeader(code)
+}
+
+// implicitHeader writes the header of a response written without calling
+// WriteHeader, and reports whether the response has been replaced.
+func (rw *responseWriter) implicitHeader() bool {
+	if rw.code == 0 {
+		if rw.replace != nil {
+			rw.WriteHeader(http.StatusOK)
+		} else {
+			rw.code = http.StatusOK
+		}
+	}
+	return rw.replaced
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.implicitHeader() {
+		return len(b), nil
+	}
+
+	write := rw.w.Write
+	if rw.hooks.Write != nil {
+		write = rw.hooks.Write
+	}
+
+	n, err := write(b)
+	rw.written += int64(n)
+	return n, err
+}
+
+func (rw *responseWriter) Status() int {
+	if rw.code == 0 {
+		return http.StatusOK
+	}
+	return rw.code
+}
+
+func (rw *responseWriter) BytesWritten() int64 {
+	return rw.written
+}
+
+func (rw *responseWriter) Written() bool {
+	return rw.code != 0
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.w
+}
+
+type rwFlusher struct{ rw *responseWriter }
+
+func (f rwFlusher) Flush() {
+	if f.rw.implicitHeader() {
+		return
+	}
+
+	if f.rw.hooks.Flush != nil {
+		f.rw.hooks.Flush()
+		return
+	}
+	f.rw.w.(http.Flusher).Flush()
+}
+
+type rwHijacker struct{ rw *responseWriter }
+
+func (h rwHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijack := h.rw.w.(http.Hijacker).Hijack
+	if h.rw.hooks.Hijack != nil {
+		hijack = h.rw.hooks.Hijack
+	}
+
+	conn, brw, err := hijack()
+	if err == nil && h.rw.code == 0 {
+		h.rw.code = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+type rwReaderFrom struct{ rw *responseWriter }
+
+func (rf rwReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	if rf.rw.implicitHeader() {
+		return io.Copy(io.Discard, src)
+	}
+
+	n, err := rf.rw.w.(io.ReaderFrom).ReadFrom(src)
+	rf.rw.written += n
+	return n, err
+}
+
+type rwPusher struct{ rw *responseWriter }
+
+func (p rwPusher) Push(target string, opts *http.PushOptions) error {
+	return p.rw.w.(http.Pusher).Push(target, opts)
+}
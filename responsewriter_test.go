@@ -0,0 +1,274 @@
+package mux_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/cache"
+	"github.com/josestg/mux/compress"
+	"github.com/josestg/mux/etag"
+	"github.com/josestg/mux/metrics"
+	"github.com/josestg/mux/tracetest"
+	"github.com/josestg/mux/websocket"
+)
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (p *pushRecorder) Push(target string, _ *http.PushOptions) error {
+	p.pushed = append(p.pushed, target)
+	return nil
+}
+
+type readFromRecorder struct {
+	http.ResponseWriter
+}
+
+func (r readFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	return io.Copy(r.ResponseWriter, src)
+}
+
+func TestNewResponseWriter_Interfaces(t *testing.T) {
+	tests := []struct {
+		w           http.ResponseWriter
+		expFlusher  bool
+		expHijacker bool
+		expReader   bool
+		expPusher   bool
+	}{
+		{w: struct{ http.ResponseWriter }{httptest.NewRecorder()}},
+		{w: httptest.NewRecorder(), expFlusher: true},
+		{w: hijackRecorder{httptest.NewRecorder()}, expFlusher: true, expHijacker: true},
+		{w: &pushRecorder{ResponseRecorder: httptest.NewRecorder()}, expFlusher: true, expPusher: true},
+		{w: readFromRecorder{struct{ http.ResponseWriter }{httptest.NewRecorder()}}, expReader: true},
+	}
+
+	for i, tc := range tests {
+		rw := mux.NewResponseWriter(tc.w)
+
+		if _, ok := rw.(http.Flusher); ok != tc.expFlusher {
+			t.Fatalf("%d: expected http.Flusher %v; got %v", i, tc.expFlusher, ok)
+		}
+		if _, ok := rw.(http.Hijacker); ok != tc.expHijacker {
+			t.Fatalf("%d: expected http.Hijacker %v; got %v", i, tc.expHijacker, ok)
+		}
+		if _, ok := rw.(io.ReaderFrom); ok != tc.expReader {
+			t.Fatalf("%d: expected io.ReaderFrom %v; got %v", i, tc.expReader, ok)
+		}
+		if _, ok := rw.(http.Pusher); ok != tc.expPusher {
+			t.Fatalf("%d: expected http.Pusher %v; got %v", i, tc.expPusher, ok)
+		}
+
+		if rw.Unwrap() != tc.w {
+			t.Fatalf("%d: expected Unwrap to return the wrapped writer", i)
+		}
+	}
+}
+
+func TestNewResponseWriter_Status(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := mux.NewResponseWriter(readFromRecorder{rec})
+
+	if rw.Written() || rw.Status() != http.StatusOK {
+		t.Fatalf("expected nothing written; got %v %d", rw.Written(), rw.Status())
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	rw.WriteHeader(http.StatusConflict)
+	_, _ = io.WriteString(rw, "hello")
+	_, _ = rw.(io.ReaderFrom).ReadFrom(strings.NewReader(" world"))
+
+	if !rw.Written() || rw.Status() != http.StatusCreated {
+		t.Fatalf("expected status code %d; got %d", http.StatusCreated, rw.Status())
+	}
+
+	if rw.BytesWritten() != 11 || rec.Body.String() != "hello world" {
+		t.Fatalf("expected 11 bytes written; got %d %q", rw.BytesWritten(), rec.Body.String())
+	}
+
+	pr := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	if err := mux.NewResponseWriter(pr).(http.Pusher).Push("/app.js", nil); err != nil || len(pr.pushed) != 1 {
+		t.Fatalf("expected push forwarded; got %v %v", err, pr.pushed)
+	}
+}
+
+// find looks for the optional interface T through the Unwrap chain of w,
+// the same way http.ResponseController does.
+func find[T any](w http.ResponseWriter) (T, bool) {
+	for {
+		if v, ok := w.(T); ok {
+			return v, true
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		w = u.Unwrap()
+	}
+}
+
+func TestHookResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	var codes []int
+	var body strings.Builder
+	w := mux.HookResponseWriter(readFromRecorder{rec}, mux.ResponseWriterHooks{
+		WriteHeader: func(code int) { codes = append(codes, code) },
+		Write:       body.Write,
+	})
+
+	if _, ok := w.(io.ReaderFrom); ok {
+		t.Fatalf("expected io.ReaderFrom dropped when Write is hooked")
+	}
+
+	w.WriteHeader(http.StatusEarlyHints)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = io.Copy(w, strings.NewReader("hooked"))
+
+	if len(codes) != 2 || codes[0] != http.StatusEarlyHints || codes[1] != http.StatusCreated {
+		t.Fatalf("expected status codes [103 201]; got %v", codes)
+	}
+
+	if w.Status() != http.StatusCreated || w.BytesWritten() != 6 {
+		t.Fatalf("expected status code 201 and 6 bytes; got %d and %d", w.Status(), w.BytesWritten())
+	}
+
+	if body.String() != "hooked" || rec.Body.Len() != 0 {
+		t.Fatalf("expected body written by the hook only; got %q and %q", body.String(), rec.Body.String())
+	}
+}
+
+func TestNewResponseWriter_Middlewares(t *testing.T) {
+	handler := mux.New(mux.WithTracer(tracetest.NewRecorder()))
+	handler.Use(
+		metrics.New().Middleware,
+		compress.New(),
+		mux.Timeout(time.Second),
+		etag.New(),
+		cache.New().Middleware,
+		mux.RequestBody(1<<10),
+	)
+
+	handler.HandleFunc(http.MethodGet, "/hijack", func(w http.ResponseWriter, r *http.Request) {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Errorf("expected http.Hijacker through the middlewares")
+			return
+		}
+
+		conn, brw, err := hj.Hijack()
+		if err != nil {
+			t.Errorf("expected no error; got %v", err)
+			return
+		}
+		defer conn.Close()
+
+		_, _ = brw.WriteString("HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n")
+		_ = brw.Flush()
+	})
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL + "/hijack")
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status code %d; got %d", http.StatusNoContent, resp.StatusCode)
+	}
+}
+
+func TestRequestBody_Hijacker(t *testing.T) {
+	handler := mux.New()
+	handler.Use(mux.RequestBody(1 << 10))
+	handler.HandleFunc(http.MethodPost, "/hijack", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Hijacker); !ok {
+			t.Errorf("expected http.Hijacker kept by the type assertion")
+		}
+		if _, ok := w.(io.ReaderFrom); !ok {
+			t.Errorf("expected io.ReaderFrom kept by the type assertion")
+		}
+	})
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	resp, err := http.Post(server.URL+"/hijack", "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestBufferingWriters_Hijack(t *testing.T) {
+	tests := []mux.MiddlewareFunc{
+		compress.New(),
+		mux.Timeout(time.Second),
+		etag.New(),
+		cache.New().Middleware,
+	}
+
+	for i, mw := range tests {
+		handler := mux.New()
+		handler.Use(mw)
+
+		var hijackErr error
+		handler.HandleFunc(http.MethodGet, "/hijack", func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := w.(http.Flusher); !ok {
+				t.Errorf("%d: expected http.Flusher kept by the type assertion", i)
+			}
+
+			var conn *websocket.Conn
+			conn, hijackErr = websocket.Upgrade(w, r)
+			if hijackErr == nil {
+				_ = conn.Close(websocket.CloseNormal, "")
+			}
+		})
+
+		server := httptest.NewServer(handler)
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/hijack", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			server.Close()
+			t.Fatalf("%d: expected no error; got %v", i, err)
+		}
+		_ = resp.Body.Close()
+		server.Close()
+
+		if hijackErr != nil {
+			t.Fatalf("%d: expected no error; got %v", i, hijackErr)
+		}
+
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			t.Fatalf("%d: expected status code %d; got %d", i, http.StatusSwitchingProtocols, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/josestg/mux/internal/trie"
+)
+
+// Route holds the information of a registered route.
+type Route struct {
+	// Method is the HTTP method the route is registered for.
+	Method string
+
+	// Pattern is the URL path pattern as it was registered,
+	// e.g. /books/:id.
+	Pattern string
+
+	// Name is the optional name given by WithName.
+	Name string
+
+	// Version is the name of the Version the route is registered in, empty
+	// for the routes registered on the Mux.
+	Version string
+
+	// Deprecation is set by WithDeprecation, nil when the route is not
+	// deprecated.
+	Deprecation *Deprecation
+
+	values map[any]any
+}
+
+// VersionedPattern returns the Pattern prefixed by the Version, e.g.
+// /v2/books/:id, which tells apart the routes registered for the same
+// pattern in several versions.
+func (rt *Route) VersionedPattern() string {
+	if rt.Version == "" {
+		return rt.Pattern
+	}
+	return strings.TrimSuffix("/"+rt.Version+rt.Pattern, "/")
+}
+
+// Value returns the value associated with key by WithValue, or nil.
+func (rt *Route) Value(key any) any {
+	return rt.values[key]
+}
+
+// RouteOptionApplier is a function for applying route option.
+type RouteOptionApplier func(rt *Route)
+
+// WithName is a route option applier naming the Route, so it can be referred
+// to by tools and tests independently of its pattern.
+func WithName(name string) RouteOptionApplier {
+	return func(rt *Route) {
+		rt.Name = name
+	}
+}
+
+// WithValue is a route option applier associating the value with key in the
+// Route. It lets middlewares be configured per route. Like context keys, the
+// key should be of an unexported type to avoid collisions.
+func WithValue(key, value any) RouteOptionApplier {
+	return func(rt *Route) {
+		if rt.values == nil {
+			rt.values = make(map[any]any)
+		}
+		rt.values[key] = value
+	}
+}
+
+// route is the http.Handler stored in the trie. It keeps the Route
+// information next to the registered handler.
+type route struct {
+	Route
+	handler http.Handler
+
+	// next is the route registered after a route gated by WithFlag.
+	next *route
+}
+
+func newRoute(method string, path string, handler http.Handler, appliers []RouteOptionApplier) *route {
+	rt := &route{
+		Route:   Route{Method: method, Pattern: path},
+		handler: handler,
+	}
+
+	for _, apply := range appliers {
+		apply(&rt.Route)
+	}
+
+	return rt
+}
+
+func (rt *route) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.handler.ServeHTTP(w, r)
+}
+
+// routeTable holds the routes registered on a Mux or on a Version.
+type routeTable struct {
+	router *trie.Trie
+
+	// routes are the routes inserted into the router by method and
+	// pattern, and flagged reports whether one is gated by a feature flag.
+	routes  map[string]*route
+	flagged bool
+
+	// names are the first routes inserted with each name.
+	names map[string]*route
+}
+
+func newRouteTable() *routeTable {
+	return &routeTable{router: trie.New(), routes: make(map[string]*route), names: make(map[string]*route)}
+}
+
+func (t *routeTable) insert(rt *route) error {
+	if rt.gated() {
+		t.flagged = true
+	}
+
+	if _, ok := t.names[rt.Name]; rt.Name != "" && !ok {
+		t.names[rt.Name] = rt
+	}
+
+	// the routes registered after a gated route are its fallbacks.
+	key := strings.ToUpper(rt.Method) + " " + rt.Pattern
+	if prev, ok := t.routes[key]; ok {
+		for prev.next != nil {
+			prev = prev.next
+		}
+
+		if prev.gated() {
+			prev.next = rt
+			return nil
+		}
+	}
+
+	if err := t.router.InsertHandler(rt.Method, rt.Pattern, rt); err != nil {
+		return err
+	}
+
+	t.routes[key] = rt
+	return nil
+}
+
+// list returns the routes of the table sorted by pattern and method,
+// including the ones registered after a gated route.
+func (t *routeTable) list() []*Route {
+	routes := make([]*Route, 0, len(t.routes))
+	for _, rt := range t.routes {
+		for ; rt != nil; rt = rt.next {
+			routes = append(routes, &rt.Route)
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// GetRoute returns the matched Route. It returns nil when the request
+// does not match any registered route.
+func GetRoute(ctx context.Context) *Route {
+	return getRequestInfo(ctx).route
+}
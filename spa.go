@@ -0,0 +1,68 @@
+package mux
+
+import (
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// spaIndex is the name of the file served by the SPA fallback.
+const spaIndex = "index.html"
+
+// WithSPAFallback is an option applier serving the index.html of fsys to the
+// unmatched GET and HEAD requests accepting text/html, so the client-side
+// routes of a single-page application work on deep links. Requests under
+// the excluded prefixes, e.g. "/api", and the requests not accepting
+// text/html are still served by the RoutesNotFoundHandler.
+//
+// It wraps the RoutesNotFoundHandler set so far, so it must be applied after
+// the appliers replacing it.
+func WithSPAFallback(fsys fs.FS, excludedPrefixes ...string) OptionApplier {
+	return func(o *Options) {
+		o.RoutesNotFoundHandler = &spaFallback{
+			files:    &fileServer{fsys: fsys, opts: &FileServerOptions{Precompressed: true}},
+			excluded: excludedPrefixes,
+			next:     o.RoutesNotFoundHandler,
+		}
+	}
+}
+
+type spaFallback struct {
+	files    *fileServer
+	excluded []string
+	next     http.Handler
+}
+
+func (s *spaFallback) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.accepts(r) {
+		s.next.ServeHTTP(w, r)
+		return
+	}
+
+	f, info, err := s.files.open(spaIndex)
+	if err != nil {
+		s.next.ServeHTTP(w, r)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Cache-Control", "no-cache")
+	s.files.serveFile(w, r, f, info, spaIndex)
+}
+
+func (s *spaFallback) accepts(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	for _, prefix := range s.excluded {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+			return false
+		}
+	}
+
+	// A bare */* is not enough, clients like curl and fetch send it too.
+	q, spec := quality(parseAccept(r.Header.Get("Accept")), "text/html")
+	return q > 0 && spec > 0
+}
@@ -0,0 +1,83 @@
+package mux_test
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/josestg/mux"
+)
+
+func TestWithSPAFallback(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":    {Data: []byte("<div id=app></div>")},
+		"assets/app.js": {Data: []byte("app")},
+	}
+
+	handler := mux.New(mux.WithSPAFallback(fsys, "/api/"))
+	assets, err := fs.Sub(fsys, "assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler.ServeFiles("/assets", assets)
+	handler.Handle(http.MethodGet, "/api/books", fakeHandler(0))
+
+	const html = "text/html,application/xhtml+xml,*/*;q=0.8"
+
+	tests := []struct {
+		method         string
+		path           string
+		accept         string
+		expStatusCode  int
+		expContentType string
+		expBody        string
+	}{
+		{method: http.MethodGet, path: "/", accept: html, expStatusCode: 200, expContentType: "text/html; charset=utf-8", expBody: "<div id=app></div>"},
+		{method: http.MethodGet, path: "/books/1/reviews", accept: html, expStatusCode: 200, expContentType: "text/html; charset=utf-8", expBody: "<div id=app></div>"},
+		{method: http.MethodHead, path: "/books/1", accept: "text/*", expStatusCode: 200, expContentType: "text/html; charset=utf-8"},
+		{method: http.MethodGet, path: "/assets/app.js", accept: html, expStatusCode: 200, expBody: "app"},
+		{method: http.MethodGet, path: "/books/1", accept: "*/*", expStatusCode: 404, expContentType: "text/plain; charset=utf-8"},
+		{method: http.MethodGet, path: "/books/1", accept: "text/html;q=0, */*", expStatusCode: 404, expContentType: "text/plain; charset=utf-8"},
+		{method: http.MethodPost, path: "/books", accept: html, expStatusCode: 404, expContentType: "text/plain; charset=utf-8"},
+		{method: http.MethodGet, path: "/api/authors", accept: "application/json", expStatusCode: 404, expContentType: mux.ProblemContentType},
+		{method: http.MethodGet, path: "/api", accept: html, expStatusCode: 404, expContentType: "text/plain; charset=utf-8"},
+		{method: http.MethodGet, path: "/apiary", accept: html, expStatusCode: 200, expContentType: "text/html; charset=utf-8"},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		r.Header.Set("Accept", tc.accept)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("%d: expected status code %d; got %d", i, tc.expStatusCode, rec.Code)
+		}
+
+		if tc.expContentType != "" && rec.Header().Get("Content-Type") != tc.expContentType {
+			t.Fatalf("%d: expected content type %q; got %q", i, tc.expContentType, rec.Header().Get("Content-Type"))
+		}
+
+		if tc.expBody != "" && rec.Body.String() != tc.expBody {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.expBody, rec.Body.String())
+		}
+	}
+}
+
+func TestWithSPAFallback_MissingIndex(t *testing.T) {
+	handler := mux.New(mux.WithSPAFallback(fstest.MapFS{}))
+
+	r := httptest.NewRequest(http.MethodGet, "/books", nil)
+	r.Header.Set("Accept", "text/html")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status code %d; got %d", http.StatusNotFound, rec.Code)
+	}
+}
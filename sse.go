@@ -0,0 +1,34 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/josestg/mux/sse"
+)
+
+// SSEHandler streams Server-Sent Events. The stream ends when it returns or
+// when ctx is done, i.e. the client disconnected.
+type SSEHandler func(ctx context.Context, s *sse.Stream) error
+
+// HandleSSE registers a GET route streaming the events sent by the
+// SSEHandler. The route opts out of the Timeout middleware, which the route
+// options can override.
+//
+// When the response can not be streamed, sse.ErrStreamingUnsupported is
+// written by the ErrorHandler. The errors of the SSEHandler can not be
+// reported to the client, which only sees the stream end.
+func (m *Mux) HandleSSE(path string, handler SSEHandler, appliers ...RouteOptionApplier) {
+	appliers = append([]RouteOptionApplier{WithTimeout(0)}, appliers...)
+
+	m.Handle(http.MethodGet, path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := sse.NewStream(w, r)
+		if err != nil {
+			m.options.ErrorHandler(w, r, err)
+			return
+		}
+		defer s.Close()
+
+		_ = handler(r.Context(), s)
+	}), appliers...)
+}
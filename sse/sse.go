@@ -0,0 +1,305 @@
+// Package sse implements Server-Sent Events streams.
+//
+// A Stream sets the event stream headers, writes the events as they are
+// sent, keeps the connection alive with heartbeat comments and ends when the
+// client disconnects:
+//
+//	func events(ctx context.Context, s *sse.Stream) error {
+//		for msg := range subscribe(ctx, s.LastEventID()) {
+//			if err := s.Send(sse.Event{ID: msg.ID, Data: msg.Body}); err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	}
+//
+// See: https://html.spec.whatwg.org/multipage/server-sent-events.html.
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrStreamingUnsupported is returned by NewStream when the
+	// ResponseWriter can not be flushed.
+	ErrStreamingUnsupported = errors.New("sse: streaming unsupported")
+
+	// ErrInvalidEvent is returned by Send when the ID or the name of the
+	// event contains a line break.
+	ErrInvalidEvent = errors.New("sse: invalid event")
+
+	// ErrClosed is returned by Send after the Stream is closed.
+	ErrClosed = errors.New("sse: stream closed")
+)
+
+// ContentType is the content type of event streams.
+const ContentType = "text/event-stream"
+
+// Event is a message of the stream.
+type Event struct {
+	// ID is the event ID, reported back by reconnecting clients in the
+	// Last-Event-ID header.
+	ID string
+
+	// Event is the event name. Clients dispatch unnamed events as message.
+	Event string
+
+	// Data is the payload. Multiple lines are sent as multiple data fields.
+	Data string
+
+	// Retry is the reconnection time the client should use.
+	Retry time.Duration
+}
+
+// OptionApplier is a function for applying option.
+type OptionApplier func(o *Options)
+
+// Options holds the Stream optional fields.
+type Options struct {
+	// Heartbeat is the interval of the comments keeping the connection
+	// alive through proxies. Zero disables heartbeats.
+	Heartbeat time.Duration
+
+	// Retry is the reconnection time sent when the stream opens. Zero
+	// keeps the client default.
+	Retry time.Duration
+}
+
+// Default is a default option applier.
+func Default() OptionApplier {
+	return func(o *Options) {
+		o.Heartbeat = 15 * time.Second
+		o.Retry = 0
+	}
+}
+
+// WithHeartbeat sets the Heartbeat.
+func WithHeartbeat(d time.Duration) OptionApplier {
+	return func(o *Options) {
+		o.Heartbeat = d
+	}
+}
+
+// WithRetry sets the Retry.
+func WithRetry(d time.Duration) OptionApplier {
+	return func(o *Options) {
+		o.Retry = d
+	}
+}
+
+// Stream is an open event stream. It is safe for concurrent use.
+type Stream struct {
+	ctx         context.Context
+	lastEventID string
+
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	closed  bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewStream opens an event stream on w with Default option. It writes the
+// response header, so it must be called before anything else is written.
+// The stream must be closed before the handler returns.
+func NewStream(w http.ResponseWriter, r *http.Request, appliers ...OptionApplier) (*Stream, error) {
+	var options Options
+	Default()(&options)
+
+	for _, apply := range appliers {
+		apply(&options)
+	}
+
+	flusher := findFlusher(w)
+	if flusher == nil {
+		return nil, ErrStreamingUnsupported
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	s := &Stream{
+		ctx:         r.Context(),
+		lastEventID: lastEventID,
+		w:           w,
+		flusher:     flusher,
+		done:        make(chan struct{}),
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", ContentType)
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+
+	var b strings.Builder
+	if options.Retry > 0 {
+		writeRetry(&b, options.Retry)
+		b.WriteByte('\n')
+	}
+
+	if err := s.write(b.String()); err != nil {
+		return nil, err
+	}
+
+	if options.Heartbeat > 0 {
+		s.wg.Add(1)
+		go s.heartbeat(options.Heartbeat)
+	}
+
+	return s, nil
+}
+
+// findFlusher returns the http.Flusher of w, looking through the writers
+// wrapping it.
+func findFlusher(w http.ResponseWriter) http.Flusher {
+	for {
+		if f, ok := w.(http.Flusher); ok {
+			return f
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+}
+
+// LastEventID returns the ID of the last event received by a reconnecting
+// client, from the Last-Event-ID header or the lastEventId query parameter.
+func (s *Stream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Context returns the context of the request, done when the client
+// disconnects.
+func (s *Stream) Context() context.Context {
+	return s.ctx
+}
+
+// Send writes the event and flushes it to the client.
+func (s *Stream) Send(e Event) error {
+	if strings.ContainsAny(e.ID, "\r\n\x00") || strings.ContainsAny(e.Event, "\r\n") {
+		return ErrInvalidEvent
+	}
+
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + e.Event + "\n")
+	}
+	if e.Retry > 0 {
+		writeRetry(&b, e.Retry)
+	}
+
+	data := strings.ReplaceAll(e.Data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\r", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteByte('\n')
+
+	return s.write(b.String())
+}
+
+// Comment writes a comment, ignored by the clients.
+func (s *Stream) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(": " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	b.WriteByte('\n')
+	return s.write(b.String())
+}
+
+// Close stops the heartbeats and waits for them to end. Send fails after
+// Close.
+func (s *Stream) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.done)
+	s.wg.Wait()
+}
+
+func (s *Stream) write(msg string) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	if msg != "" {
+		if _, err := s.w.Write([]byte(msg)); err != nil {
+			return err
+		}
+	}
+
+	s.flusher.Flush()
+	return nil
+}
+
+func (s *Stream) heartbeat(every time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeRetry(b *strings.Builder, d time.Duration) {
+	b.WriteString("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n")
+}
+
+// Handler returns an http.Handler streaming the events sent by fn. The
+// stream is closed when fn returns. When the ResponseWriter can not be
+// flushed, 500 Internal Server Error is written instead.
+func Handler(fn func(ctx context.Context, s *Stream) error, appliers ...OptionApplier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := NewStream(w, r, appliers...)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer s.Close()
+
+		_ = fn(r.Context(), s)
+	})
+}
@@ -0,0 +1,167 @@
+package sse_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux/sse"
+)
+
+// noFlusher hides the http.Flusher of the recorder.
+type noFlusher struct {
+	http.ResponseWriter
+}
+
+// unwrapper exposes the recorder through Unwrap only.
+type unwrapper struct {
+	w http.ResponseWriter
+}
+
+func (u unwrapper) Header() http.Header         { return u.w.Header() }
+func (u unwrapper) Write(b []byte) (int, error) { return u.w.Write(b) }
+func (u unwrapper) WriteHeader(code int)        { u.w.WriteHeader(code) }
+func (u unwrapper) Unwrap() http.ResponseWriter { return u.w }
+
+func TestStream_Send(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+
+	s, err := sse.NewStream(rec, r, sse.WithRetry(3*time.Second), sse.WithHeartbeat(0))
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	events := []sse.Event{
+		{Data: "hello"},
+		{ID: "1", Event: "book", Data: "line 1\nline 2\r\nline 3"},
+		{Retry: time.Second, Data: ""},
+	}
+
+	for _, e := range events {
+		if err := s.Send(e); err != nil {
+			t.Fatalf("expected no error; got %v", err)
+		}
+	}
+
+	if err := s.Comment("bye"); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	s.Close()
+
+	if err := s.Send(sse.Event{Data: "late"}); err != sse.ErrClosed {
+		t.Fatalf("expected error %v; got %v", sse.ErrClosed, err)
+	}
+
+	exp := "retry: 3000\n\n" +
+		"data: hello\n\n" +
+		"id: 1\nevent: book\ndata: line 1\ndata: line 2\ndata: line 3\n\n" +
+		"retry: 1000\ndata: \n\n" +
+		": bye\n\n"
+
+	if got := rec.Body.String(); got != exp {
+		t.Fatalf("expected body %q; got %q", exp, got)
+	}
+
+	h := rec.Header()
+	if h.Get("Content-Type") != sse.ContentType || h.Get("Cache-Control") != "no-cache" {
+		t.Fatalf("expected event stream headers; got %v", h)
+	}
+
+	if !rec.Flushed {
+		t.Fatalf("expected flushed")
+	}
+}
+
+func TestStream_InvalidEvent(t *testing.T) {
+	s, err := sse.NewStream(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	defer s.Close()
+
+	for _, e := range []sse.Event{{ID: "1\n2"}, {Event: "a\rb"}} {
+		if err := s.Send(e); err != sse.ErrInvalidEvent {
+			t.Fatalf("expected error %v; got %v", sse.ErrInvalidEvent, err)
+		}
+	}
+}
+
+func TestNewStream_Flusher(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if _, err := sse.NewStream(noFlusher{httptest.NewRecorder()}, r); err != sse.ErrStreamingUnsupported {
+		t.Fatalf("expected error %v; got %v", sse.ErrStreamingUnsupported, err)
+	}
+
+	rec := httptest.NewRecorder()
+	s, err := sse.NewStream(unwrapper{rec}, r, sse.WithHeartbeat(0))
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+	defer s.Close()
+
+	if err := s.Send(sse.Event{Data: "x"}); err != nil || !rec.Flushed {
+		t.Fatalf("expected flushed through Unwrap; got %v", err)
+	}
+}
+
+func TestStream_LastEventID(t *testing.T) {
+	tests := []struct {
+		target string
+		header string
+		exp    string
+	}{
+		{target: "/", exp: ""},
+		{target: "/", header: "42", exp: "42"},
+		{target: "/?lastEventId=7", exp: "7"},
+		{target: "/?lastEventId=7", header: "42", exp: "42"},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		if tc.header != "" {
+			r.Header.Set("Last-Event-ID", tc.header)
+		}
+
+		s, err := sse.NewStream(httptest.NewRecorder(), r, sse.WithHeartbeat(0))
+		if err != nil {
+			t.Fatalf("%d: expected no error; got %v", i, err)
+		}
+		s.Close()
+
+		if got := s.LastEventID(); got != tc.exp {
+			t.Fatalf("%d: expected %q; got %q", i, tc.exp, got)
+		}
+	}
+}
+
+func TestHandler_Heartbeat(t *testing.T) {
+	server := httptest.NewServer(sse.Handler(func(ctx context.Context, s *sse.Stream) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, sse.WithHeartbeat(10*time.Millisecond)))
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	buf := make([]byte, len(": heartbeat\n\n"))
+	if _, err := io.ReadFull(resp.Body, buf); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if got := string(buf); !strings.HasPrefix(got, ": heartbeat") {
+		t.Fatalf("expected heartbeat; got %q", got)
+	}
+
+	// closing the body disconnects the client and ends the handler.
+	_ = resp.Body.Close()
+}
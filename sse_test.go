@@ -0,0 +1,100 @@
+package mux_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/sse"
+	"github.com/josestg/mux/tracetest"
+)
+
+func TestMux_HandleSSE(t *testing.T) {
+	handler := mux.New(mux.WithTracer(tracetest.NewRecorder()))
+	handler.Use(mux.Timeout(20*time.Millisecond), mux.RequestBody(1<<10))
+
+	done := make(chan error, 1)
+	handler.HandleSSE("/books/:id/events", func(ctx context.Context, s *sse.Stream) error {
+		start, _ := strconv.Atoi(s.LastEventID())
+		for i := start + 1; i <= start+3; i++ {
+			// outlive the Timeout middleware between the events.
+			time.Sleep(10 * time.Millisecond)
+
+			id := strconv.Itoa(i)
+			err := s.Send(sse.Event{ID: id, Event: "update", Data: mux.GetVars(ctx).Get("id") + ":" + id})
+			if err != nil {
+				return err
+			}
+		}
+
+		<-ctx.Done()
+		done <- ctx.Err()
+		return nil
+	})
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/books/7/events", nil)
+	req.Header.Set("Last-Event-ID", "10")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != sse.ContentType {
+		t.Fatalf("expected an event stream; got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	var data []string
+	sc := bufio.NewScanner(resp.Body)
+	for len(data) < 3 && sc.Scan() {
+		if v := strings.TrimPrefix(sc.Text(), "data: "); v != sc.Text() {
+			data = append(data, v)
+		}
+	}
+
+	if exp := "7:11,7:12,7:13"; strings.Join(data, ",") != exp {
+		t.Fatalf("expected data %q; got %q", exp, strings.Join(data, ","))
+	}
+
+	_ = resp.Body.Close()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("expected error %v; got %v", context.Canceled, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the handler to end on disconnect")
+	}
+}
+
+func TestMux_HandleSSE_Unsupported(t *testing.T) {
+	// the tracer wraps the writer given to the handler, which must not
+	// claim to be an http.Flusher.
+	handler := mux.New(mux.WithTracer(tracetest.NewRecorder()))
+	handler.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// hides the http.Flusher of w.
+			next.ServeHTTP(struct{ http.ResponseWriter }{w}, r)
+		})
+	})
+	handler.HandleSSE("/events", func(ctx context.Context, s *sse.Stream) error {
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status code %d; got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
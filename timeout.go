@@ -0,0 +1,231 @@
+package mux
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type timeoutKey struct{}
+
+// WithTimeout is a route option applier overriding the deadline set by the
+// Timeout middleware. A zero or negative duration opts the route out, e.g.
+// for streaming endpoints.
+func WithTimeout(d time.Duration) RouteOptionApplier {
+	return WithValue(timeoutKey{}, d)
+}
+
+// Timeout returns a Middleware which applies a deadline of d to the request
+// context. Routes registered with WithTimeout use their own deadline.
+//
+// The response is buffered until the handler returns, so when the deadline
+// is exceeded, 503 Service Unavailable is written instead and the later
+// writes of the handler fail with http.ErrHandlerTimeout. Unlike
+// http.TimeoutHandler, the writer implements http.Flusher: flushing sends
+// the buffered response, after which the status can no longer be replaced
+// and an overrunning handler only gets its context cancelled. Hijacking the
+// connection commits the response the same way, as long as the deadline is
+// not exceeded.
+func Timeout(d time.Duration) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := d
+			if rt := GetRoute(r.Context()); rt != nil {
+				if v, ok := rt.Value(timeoutKey{}).(time.Duration); ok {
+					timeout = v
+				}
+			}
+
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, h: w.Header().Clone()}
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+				}()
+
+				next.ServeHTTP(HookResponseWriter(w, ResponseWriterHooks{
+					Header:      tw.header,
+					WriteHeader: tw.writeHeader,
+					Write:       tw.write,
+					Flush:       tw.flush,
+					Hijack:      tw.hijack,
+				}), r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				panic(p)
+			case <-done:
+				tw.finish()
+			case <-ctx.Done():
+				tw.timeout(r)
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers the response of a handler running under a deadline.
+type timeoutWriter struct {
+	w http.ResponseWriter
+	h http.Header
+
+	mu          sync.Mutex
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	committed   bool
+	hijacked    bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) header() http.Header {
+	return tw.h
+}
+
+// writeHeader records the status code. Informational 1xx status codes are
+// sent right away with the header set so far.
+func (tw *timeoutWriter) writeHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+
+	if code < http.StatusOK && code != http.StatusSwitchingProtocols {
+		tw.copyHeaderLocked()
+		tw.w.WriteHeader(code)
+		return
+	}
+
+	tw.code = code
+	tw.wroteHeader = true
+}
+
+func (tw *timeoutWriter) write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	if !tw.wroteHeader {
+		tw.code = http.StatusOK
+		tw.wroteHeader = true
+	}
+
+	if tw.committed {
+		return tw.w.Write(b)
+	}
+	return tw.buf.Write(b)
+}
+
+// flush sends the buffered response and flushes the underlying writer.
+func (tw *timeoutWriter) flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.hijacked {
+		return
+	}
+
+	if !tw.wroteHeader {
+		tw.code = http.StatusOK
+		tw.wroteHeader = true
+	}
+
+	tw.commitLocked()
+	tw.w.(http.Flusher).Flush()
+}
+
+// hijack takes over the connection, unless the deadline is exceeded.
+func (tw *timeoutWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+
+	conn, rw, err := tw.w.(http.Hijacker).Hijack()
+	if err == nil {
+		tw.committed, tw.hijacked = true, true
+	}
+	return conn, rw, err
+}
+
+// finish sends the response once the handler has returned.
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.hijacked {
+		return
+	}
+
+	if !tw.wroteHeader {
+		tw.code = http.StatusOK
+		tw.wroteHeader = true
+	}
+
+	tw.commitLocked()
+}
+
+// timeout replaces the response with 503 Service Unavailable, unless it has
+// already been sent.
+func (tw *timeoutWriter) timeout(r *http.Request) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.timedOut = true
+	if tw.committed {
+		return
+	}
+
+	Error(tw.w, r, http.StatusServiceUnavailable)
+}
+
+// copyHeaderLocked replaces the header of the underlying writer by the one
+// of the handler.
+func (tw *timeoutWriter) copyHeaderLocked() {
+	dst := tw.w.Header()
+	for k := range dst {
+		if _, ok := tw.h[k]; !ok {
+			delete(dst, k)
+		}
+	}
+	for k, v := range tw.h {
+		dst[k] = v
+	}
+}
+
+func (tw *timeoutWriter) commitLocked() {
+	if !tw.committed {
+		tw.copyHeaderLocked()
+		tw.w.WriteHeader(tw.code)
+		tw.committed = true
+	}
+
+	if tw.buf.Len() > 0 {
+		_, _ = tw.w.Write(tw.buf.Bytes())
+		tw.buf.Reset()
+	}
+}
@@ -0,0 +1,96 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+)
+
+func TestTimeout(t *testing.T) {
+	handler := mux.New()
+	handler.Use(mux.Timeout(50 * time.Millisecond))
+
+	handler.HandleFunc(http.MethodGet, "/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Handler", "fast")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("done"))
+	})
+
+	release := make(chan struct{})
+	written := make(chan error, 1)
+	handler.HandleFunc(http.MethodGet, "/slow", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Handler", "slow")
+		<-release
+		_, err := w.Write([]byte("too late"))
+		written <- err
+	})
+
+	handler.HandleFunc(http.MethodGet, "/stream", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Errorf("expected no deadline for opted out route")
+		}
+		w.WriteHeader(http.StatusOK)
+	}, mux.WithTimeout(0))
+
+	handler.HandleFunc(http.MethodGet, "/report", func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		if !ok || time.Until(deadline) < time.Second {
+			t.Errorf("expected the route deadline to be used")
+		}
+		w.WriteHeader(http.StatusOK)
+	}, mux.WithTimeout(time.Minute))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if rec.Code != http.StatusCreated || rec.Body.String() != "done" || rec.Header().Get("X-Handler") != "fast" {
+		t.Fatalf("unexpected response: %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected %d; got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+
+	if rec.Header().Get("X-Handler") != "" {
+		t.Fatalf("expected headers of the timed out handler to be discarded")
+	}
+
+	close(release)
+	if err := <-written; err != http.ErrHandlerTimeout {
+		t.Fatalf("expected %v; got %v", http.ErrHandlerTimeout, err)
+	}
+
+	for _, path := range []string{"/stream", "/report"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected %d; got %d", path, http.StatusOK, rec.Code)
+		}
+	}
+}
+
+func TestTimeout_Flush(t *testing.T) {
+	handler := mux.New()
+	handler.Use(mux.Timeout(50 * time.Millisecond))
+
+	handler.HandleFunc(http.MethodGet, "/events", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("event"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if !rec.Flushed {
+		t.Fatalf("expected the response to be flushed")
+	}
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "event" {
+		t.Fatalf("expected the flushed response to be kept; got %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,169 @@
+package mux
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// TraceParentHeader is the W3C Trace Context header carrying the parent span.
+//
+// See: https://www.w3.org/TR/trace-context/#traceparent-header.
+const TraceParentHeader = "traceparent"
+
+// ErrInvalidTraceParent is returned when a traceparent header is malformed.
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+// Tracer starts a span for every request dispatched by the Mux.
+type Tracer interface {
+	// Start starts a new span with the given name. The parent is the span
+	// context propagated by the caller, it is invalid when the request does
+	// not carry a traceparent header. The returned context is passed down to
+	// the middlewares and the handler.
+	Start(ctx context.Context, name string, parent SpanContext) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	// SpanContext returns the identity of the span.
+	SpanContext() SpanContext
+
+	// SetAttributes sets attributes describing the span.
+	SetAttributes(attrs ...Attribute)
+
+	// SetStatus records the HTTP status code of the response.
+	SetStatus(code int)
+
+	// End completes the span.
+	End()
+}
+
+// Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Span attribute keys set by the Mux, following the OpenTelemetry HTTP
+// semantic conventions.
+const (
+	AttributeMethod = "http.request.method"
+	AttributeRoute  = "http.route"
+	AttributePath   = "url.path"
+)
+
+// SpanContext identifies a span as defined by W3C Trace Context.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// IsValid reports whether both the trace and span IDs are non-zero.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// IsSampled reports whether the sampled flag is set.
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&0x01 == 0x01
+}
+
+// TraceParent formats the span context as a version 00 traceparent value.
+func (sc SpanContext) TraceParent() string {
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" +
+		hex.EncodeToString(sc.SpanID[:]) + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// ParseTraceParent parses a traceparent header value.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, ErrInvalidTraceParent
+	}
+
+	// version 00 has exactly four fields, future versions may append more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, ErrInvalidTraceParent
+	}
+
+	fields := []struct {
+		dst []byte
+		src string
+	}{
+		{dst: sc.TraceID[:], src: parts[1]},
+		{dst: sc.SpanID[:], src: parts[2]},
+		{dst: []byte{0}, src: parts[3]},
+	}
+
+	for _, f := range fields {
+		if len(f.src) != hex.EncodedLen(len(f.dst)) || strings.ToLower(f.src) != f.src {
+			return sc, ErrInvalidTraceParent
+		}
+		if _, err := hex.Decode(f.dst, []byte(f.src)); err != nil {
+			return sc, ErrInvalidTraceParent
+		}
+	}
+
+	sc.Flags = fields[2].dst[0]
+	if !sc.IsValid() {
+		return sc, ErrInvalidTraceParent
+	}
+
+	return sc, nil
+}
+
+// InjectTraceParent sets the traceparent header of h from the span in ctx,
+// so that outgoing requests continue the current trace.
+func InjectTraceParent(ctx context.Context, h http.Header) {
+	span := GetSpan(ctx)
+	if span == nil {
+		return
+	}
+
+	if sc := span.SpanContext(); sc.IsValid() {
+		h.Set(TraceParentHeader, sc.TraceParent())
+	}
+}
+
+func contextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanContextKey, span)
+}
+
+// GetSpan returns the span started by the Mux Tracer. It returns nil when
+// no Tracer is configured.
+func GetSpan(ctx context.Context) Span {
+	span, _ := ctx.Value(spanContextKey).(Span)
+	return span
+}
+
+// startSpan starts the span of the request. The returned function records
+// the status code and ends the span.
+func (m *Mux) startSpan(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	name := r.Method
+	attrs := []Attribute{
+		{Key: AttributeMethod, Value: r.Method},
+		{Key: AttributePath, Value: r.URL.Path},
+	}
+
+	if rt := GetRoute(r.Context()); rt != nil {
+		name += " " + rt.Pattern
+		attrs = append(attrs, Attribute{Key: AttributeRoute, Value: rt.Pattern})
+	}
+
+	parent, _ := ParseTraceParent(r.Header.Get(TraceParentHeader))
+	ctx, span := m.options.Tracer.Start(r.Context(), name, parent)
+	span.SetAttributes(attrs...)
+
+	rw := NewResponseWriter(w)
+	end := func() {
+		span.SetStatus(rw.Status())
+		span.End()
+	}
+
+	return rw, r.WithContext(contextWithSpan(ctx, span)), end
+}
@@ -0,0 +1,105 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/tracetest"
+)
+
+func TestMux_Tracer(t *testing.T) {
+	recorder := tracetest.NewRecorder()
+	handler := mux.New(mux.WithTracer(recorder))
+
+	var outgoing http.Header
+	handler.HandleFunc(http.MethodGet, "/books/:id", func(w http.ResponseWriter, r *http.Request) {
+		outgoing = make(http.Header)
+		mux.InjectTraceParent(r.Context(), outgoing)
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	req := httptest.NewRequest(http.MethodGet, "/books/1", nil)
+	req.Header.Set(mux.TraceParentHeader, parent)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected %d spans; got %d", 2, len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "GET /books/:id" {
+		t.Fatalf("expected name %q; got %q", "GET /books/:id", span.Name)
+	}
+
+	if span.Status != http.StatusAccepted {
+		t.Fatalf("expected status %d; got %d", http.StatusAccepted, span.Status)
+	}
+
+	if got := span.Attributes[mux.AttributeRoute]; got != "/books/:id" {
+		t.Fatalf("expected route attribute %q; got %v", "/books/:id", got)
+	}
+
+	if got := span.Parent.TraceParent(); got != parent {
+		t.Fatalf("expected parent %q; got %q", parent, got)
+	}
+
+	if span.Context.TraceID != span.Parent.TraceID {
+		t.Fatalf("expected trace id %x; got %x", span.Parent.TraceID, span.Context.TraceID)
+	}
+
+	if got := outgoing.Get(mux.TraceParentHeader); got != span.Context.TraceParent() {
+		t.Fatalf("expected propagated traceparent %q; got %q", span.Context.TraceParent(), got)
+	}
+
+	span = spans[1]
+	if span.Name != "GET" || span.Status != http.StatusNotFound || span.Parent.IsValid() {
+		t.Fatalf("unexpected span for unmatched route: %+v", span)
+	}
+
+	if _, ok := span.Attributes[mux.AttributeRoute]; ok {
+		t.Fatalf("expected no route attribute for unmatched route")
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future"},
+		{value: "", wantErr: true},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantErr: true},
+		{value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", wantErr: true},
+		{value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", wantErr: true},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01", wantErr: true},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902bz-01", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		sc, err := mux.ParseTraceParent(tc.value)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("%q: expected error; got nil", tc.value)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%q: expected no error; got %v", tc.value, err)
+		}
+
+		if got := sc.TraceParent(); got[3:] != tc.value[3:55] {
+			t.Fatalf("%q: expected round trip; got %q", tc.value, got)
+		}
+	}
+}
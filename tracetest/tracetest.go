@@ -0,0 +1,115 @@
+// Package tracetest provides an in-memory mux.Tracer that records spans for
+// testing.
+package tracetest
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+
+	"github.com/josestg/mux"
+)
+
+// Recorder is a mux.Tracer that keeps the ended spans in memory. Trace and
+// span IDs are generated from a counter, so they are deterministic.
+type Recorder struct {
+	mu    sync.Mutex
+	seq   uint64
+	ended []*Span
+}
+
+// NewRecorder creates a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Start implements the mux.Tracer interface.
+func (r *Recorder) Start(ctx context.Context, name string, parent mux.SpanContext) (context.Context, mux.Span) {
+	r.mu.Lock()
+	r.seq++
+	seq := r.seq
+	r.mu.Unlock()
+
+	sc := mux.SpanContext{Flags: 0x01}
+	binary.BigEndian.PutUint64(sc.SpanID[:], seq)
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+	} else {
+		binary.BigEndian.PutUint64(sc.TraceID[8:], seq)
+	}
+
+	span := &Span{
+		Name:       name,
+		Parent:     parent,
+		Context:    sc,
+		Attributes: make(map[string]any),
+		recorder:   r,
+	}
+
+	return ctx, span
+}
+
+// Ended returns the spans that have ended, in the order they ended.
+func (r *Recorder) Ended() []*Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	spans := make([]*Span, len(r.ended))
+	copy(spans, r.ended)
+	return spans
+}
+
+// Reset removes all recorded spans.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ended = nil
+}
+
+// Span is a recorded span.
+type Span struct {
+	Name       string
+	Parent     mux.SpanContext
+	Context    mux.SpanContext
+	Attributes map[string]any
+	Status     int
+	Ended      bool
+
+	recorder *Recorder
+}
+
+// SpanContext implements the mux.Span interface.
+func (s *Span) SpanContext() mux.SpanContext {
+	return s.Context
+}
+
+// SetAttributes implements the mux.Span interface.
+func (s *Span) SetAttributes(attrs ...mux.Attribute) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+
+	for _, attr := range attrs {
+		s.Attributes[attr.Key] = attr.Value
+	}
+}
+
+// SetStatus implements the mux.Span interface.
+func (s *Span) SetStatus(code int) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.Status = code
+}
+
+// End implements the mux.Span interface.
+func (s *Span) End() {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+
+	if s.Ended {
+		return
+	}
+
+	s.Ended = true
+	s.recorder.ended = append(s.recorder.ended, s)
+}
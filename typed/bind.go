@@ -0,0 +1,206 @@
+package typed
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/josestg/mux"
+)
+
+// Struct tags binding request values into the fields of In.
+const (
+	TagPath   = "path"
+	TagQuery  = "query"
+	TagHeader = "header"
+)
+
+// BindError is returned when a request value cannot be bound into a field.
+type BindError struct {
+	// Source is the struct tag of the field, e.g. "query".
+	Source string
+
+	// Name is the name of the request value.
+	Name string
+
+	// Err is the parsing error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *BindError) Error() string {
+	return fmt.Sprintf("invalid %s parameter %q: %v", e.Source, e.Name, e.Err)
+}
+
+// Unwrap returns the parsing error.
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// field is a struct field bound from a request value.
+type field struct {
+	index  []int
+	source string
+	name   string
+}
+
+// plans caches the bound fields of every struct type.
+var plans sync.Map
+
+func planOf(t reflect.Type) []field {
+	if p, ok := plans.Load(t); ok {
+		return p.([]field)
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		for _, source := range []string{TagPath, TagQuery, TagHeader} {
+			name, ok := f.Tag.Lookup(source)
+			if !ok || name == "-" {
+				continue
+			}
+
+			if name == "" {
+				name = f.Name
+			}
+
+			if source == TagHeader {
+				name = http.CanonicalHeaderKey(name)
+			}
+
+			fields = append(fields, field{index: f.Index, source: source, name: name})
+			break
+		}
+	}
+
+	p, _ := plans.LoadOrStore(t, fields)
+	return p.([]field)
+}
+
+// bind sets the fields of the struct pointed by v from the path variables,
+// the query and the headers of r.
+func bind(v reflect.Value, r *http.Request) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	vars := mux.GetVars(r.Context())
+	query := r.URL.Query()
+
+	for _, f := range planOf(v.Type()) {
+		var values []string
+		switch f.source {
+		case TagPath:
+			if val, ok := vars[f.name]; ok {
+				values = []string{val}
+			}
+		case TagQuery:
+			values = query[f.name]
+		case TagHeader:
+			values = r.Header.Values(f.name)
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setValue(v.FieldByIndex(f.index), values); err != nil {
+			return &BindError{Source: f.source, Name: f.name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+func setValue(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Slice && !reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) {
+		s := reflect.MakeSlice(v.Type(), 0, len(values))
+		for _, val := range values {
+			for _, part := range strings.Split(val, ",") {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				if err := setScalar(elem, strings.TrimSpace(part)); err != nil {
+					return err
+				}
+				s = reflect.Append(s, elem)
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+
+	return setScalar(v, values[0])
+}
+
+func setScalar(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		if err := setScalar(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
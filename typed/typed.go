@@ -0,0 +1,137 @@
+// Package typed registers handlers with typed input and output on a Mux.
+//
+// A typed handler receives its input as a struct decoded from the request
+// and returns its output, which is encoded with mux.Render in the format
+// negotiated with the Accept header:
+//
+//	type getBook struct {
+//		ID     int    `path:"id"`
+//		Fields string `query:"fields"`
+//		Token  string `header:"Authorization"`
+//	}
+//
+//	typed.Handle(m, http.MethodGet, "/books/:id", func(ctx context.Context, in getBook) (book, error) {
+//		...
+//	})
+//
+// The input is bound from the JSON body first, then from the query, the
+// headers and the path variables, so the tagged fields take precedence over
+// the body. Tagged fields should be excluded from the body with `json:"-"`.
+package typed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/josestg/mux"
+)
+
+// HandlerFunc is a handler with typed input and output.
+type HandlerFunc[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// Validator is implemented by inputs which validate themselves after being
+// bound. A validation error is answered with 422 Unprocessable Entity,
+// unless it implements mux.StatusCoder.
+type Validator interface {
+	Validate() error
+}
+
+// Types holds the input and output types of a typed route. It is meant for
+// generating API documentation.
+type Types struct {
+	In  reflect.Type
+	Out reflect.Type
+}
+
+type typesKey struct{}
+
+// GetTypes returns the Types of the route, and false when the route was not
+// registered with Handle.
+func GetTypes(rt *mux.Route) (Types, bool) {
+	t, ok := rt.Value(typesKey{}).(Types)
+	return t, ok
+}
+
+// Handle registers a typed handler for the given HTTP method and URL path.
+//
+// The output is written with mux.Render, in the format negotiated with the
+// Accept header, with 201 Created for POST requests and 200 OK otherwise,
+// unless it implements mux.StatusCoder. An output of type struct{} is
+// written as 204 No Content. Errors are written by the
+// ErrorHandler of the Mux; binding errors are 400 Bad Request.
+func Handle[In, Out any](m *mux.Mux, method string, path string, h HandlerFunc[In, Out], appliers ...mux.RouteOptionApplier) {
+	types := Types{
+		In:  reflect.TypeOf((*In)(nil)).Elem(),
+		Out: reflect.TypeOf((*Out)(nil)).Elem(),
+	}
+
+	appliers = append([]mux.RouteOptionApplier{mux.WithValue(typesKey{}, types)}, appliers...)
+	m.HandleE(method, path, func(w http.ResponseWriter, r *http.Request) error {
+		var in In
+		if err := decode(r, &in); err != nil {
+			return err
+		}
+
+		if v, ok := any(&in).(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return validationError(err)
+			}
+		}
+
+		out, err := h(r.Context(), in)
+		if err != nil {
+			return err
+		}
+
+		return encode(w, r, out)
+	}, appliers...)
+}
+
+func decode(r *http.Request, in any) error {
+	if r.Body != nil && r.Body != http.NoBody {
+		err := json.NewDecoder(r.Body).Decode(in)
+		switch {
+		case err == nil, errors.Is(err, io.EOF):
+		case errors.Is(err, mux.ErrBodyTooLarge):
+			return err
+		default:
+			return mux.NewHTTPError(http.StatusBadRequest, "invalid body: "+err.Error()).Wrap(err)
+		}
+	}
+
+	if err := bind(reflect.ValueOf(in).Elem(), r); err != nil {
+		return mux.NewHTTPError(http.StatusBadRequest, err.Error()).Wrap(err)
+	}
+
+	return nil
+}
+
+func validationError(err error) error {
+	var sc mux.StatusCoder
+	if errors.As(err, &sc) {
+		return err
+	}
+	return mux.NewHTTPError(http.StatusUnprocessableEntity, err.Error()).Wrap(err)
+}
+
+func encode(w http.ResponseWriter, r *http.Request, out any) error {
+	status := http.StatusOK
+	if r.Method == http.MethodPost {
+		status = http.StatusCreated
+	}
+
+	if sc, ok := out.(mux.StatusCoder); ok {
+		status = sc.StatusCode()
+	}
+
+	if _, ok := out.(struct{}); ok {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	return mux.Render(w, r, status, out)
+}
@@ -0,0 +1,170 @@
+package typed_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/typed"
+)
+
+type updateBook struct {
+	ID      int           `path:"id" json:"-"`
+	DryRun  bool          `query:"dry_run" json:"-"`
+	Tags    []string      `query:"tag" json:"-"`
+	Timeout time.Duration `query:"timeout" json:"-"`
+	Request *string       `header:"X-Request-ID" json:"-"`
+	Title   string        `json:"title"`
+}
+
+func (in updateBook) Validate() error {
+	if in.Title == "" {
+		return errors.New("title is required")
+	}
+	return nil
+}
+
+type book struct {
+	ID      int      `json:"id"`
+	Title   string   `json:"title"`
+	DryRun  bool     `json:"dry_run"`
+	Tags    []string `json:"tags"`
+	Timeout string   `json:"timeout"`
+	Request string   `json:"request"`
+}
+
+func TestHandle(t *testing.T) {
+	m := mux.New()
+	typed.Handle(m, http.MethodPut, "/books/:id", func(ctx context.Context, in updateBook) (book, error) {
+		if in.ID == 0 {
+			return book{}, mux.NewHTTPError(http.StatusNotFound, "book 0 is not found")
+		}
+
+		var request string
+		if in.Request != nil {
+			request = *in.Request
+		}
+
+		return book{
+			ID:      in.ID,
+			Title:   in.Title,
+			DryRun:  in.DryRun,
+			Tags:    in.Tags,
+			Timeout: in.Timeout.String(),
+			Request: request,
+		}, nil
+	})
+
+	typed.Handle(m, http.MethodDelete, "/books/:id", func(ctx context.Context, in struct {
+		ID int `path:"id"`
+	}) (struct{}, error) {
+		return struct{}{}, nil
+	})
+
+	tests := []struct {
+		method        string
+		target        string
+		body          string
+		expStatusCode int
+		expBook       *book
+	}{
+		{
+			method:        http.MethodPut,
+			target:        "/books/7?dry_run=true&tag=a,b&tag=c&timeout=2s",
+			body:          `{"title":"Go"}`,
+			expStatusCode: http.StatusOK,
+			expBook: &book{
+				ID:      7,
+				Title:   "Go",
+				DryRun:  true,
+				Tags:    []string{"a", "b", "c"},
+				Timeout: "2s",
+				Request: "req-1",
+			},
+		},
+		{method: http.MethodPut, target: "/books/abc", body: `{"title":"Go"}`, expStatusCode: http.StatusBadRequest},
+		{method: http.MethodPut, target: "/books/1?dry_run=maybe", body: `{"title":"Go"}`, expStatusCode: http.StatusBadRequest},
+		{method: http.MethodPut, target: "/books/1", body: `{"title":`, expStatusCode: http.StatusBadRequest},
+		{method: http.MethodPut, target: "/books/1", body: `{}`, expStatusCode: http.StatusUnprocessableEntity},
+		{method: http.MethodPut, target: "/books/0", body: `{"title":"Go"}`, expStatusCode: http.StatusNotFound},
+		{method: http.MethodDelete, target: "/books/1", expStatusCode: http.StatusNoContent},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+		r.Header.Set("X-Request-ID", "req-1")
+
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatusCode {
+			t.Fatalf("case %d: expected %d; got %d: %s", i, tc.expStatusCode, rec.Code, rec.Body.String())
+		}
+
+		if tc.expBook == nil {
+			continue
+		}
+
+		if got := rec.Header().Get("Content-Type"); got != "application/json" {
+			t.Fatalf("case %d: expected content type %q; got %q", i, "application/json", got)
+		}
+
+		var got book
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(&got, tc.expBook) {
+			t.Fatalf("case %d: expected %+v; got %+v", i, tc.expBook, got)
+		}
+	}
+}
+
+type created struct {
+	ID int `json:"id"`
+}
+
+func (created) StatusCode() int { return http.StatusAccepted }
+
+func TestHandle_StatusAndTypes(t *testing.T) {
+	m := mux.New()
+
+	var rt *mux.Route
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rt = mux.GetRoute(r.Context())
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	typed.Handle(m, http.MethodPost, "/books", func(ctx context.Context, in book) (book, error) {
+		return in, nil
+	})
+	typed.Handle(m, http.MethodPost, "/jobs", func(ctx context.Context, in struct{}) (created, error) {
+		return created{ID: 1}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(`{"title":"Go"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %d; got %d", http.StatusCreated, rec.Code)
+	}
+
+	types, ok := typed.GetTypes(rt)
+	if !ok || types.In != reflect.TypeOf(book{}) || types.Out != reflect.TypeOf(book{}) {
+		t.Fatalf("unexpected types %+v", types)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/jobs", nil))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected %d; got %d", http.StatusAccepted, rec.Code)
+	}
+}
@@ -0,0 +1,220 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AttributeVariant is the span attribute key of the variant selected by a
+// route registered with HandleVariants.
+const AttributeVariant = "mux.variant"
+
+// Variant is one of the handlers of a route registered with HandleVariants.
+type Variant struct {
+	// Name identifies the variant, e.g. in the logs.
+	Name string
+
+	// Weight is the share of the requests sent to the variant, relative to
+	// the sum of the weights. A zero weight disables the variant.
+	Weight int
+
+	// Handler serves the requests sent to the variant.
+	Handler http.Handler
+}
+
+// VariantOptions holds the optional fields of HandleVariants.
+type VariantOptions struct {
+	// StickyKey returns the key pinning the request to a variant, e.g. a
+	// user ID. The requests with the same key are sent to the same variant
+	// as long as the weights do not change. An empty key selects the
+	// variant randomly.
+	StickyKey func(r *http.Request) string
+
+	// Source is the random source of the selection, seeded with the
+	// current time when nil.
+	Source rand.Source
+
+	// RouteOptions are applied to the registered route.
+	RouteOptions []RouteOptionApplier
+}
+
+// VariantOptionApplier is a function for applying variant option.
+type VariantOptionApplier func(o *VariantOptions)
+
+// WithStickyKey is a variant option applier setting the StickyKey.
+func WithStickyKey(fn func(r *http.Request) string) VariantOptionApplier {
+	return func(o *VariantOptions) {
+		o.StickyKey = fn
+	}
+}
+
+// WithStickyCookie is a variant option applier using the value of the named
+// cookie as the StickyKey.
+func WithStickyCookie(name string) VariantOptionApplier {
+	return WithStickyKey(func(r *http.Request) string {
+		c, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return c.Value
+	})
+}
+
+// WithStickyHeader is a variant option applier using the value of the
+// header as the StickyKey.
+func WithStickyHeader(name string) VariantOptionApplier {
+	return WithStickyKey(func(r *http.Request) string {
+		return r.Header.Get(name)
+	})
+}
+
+// WithSource is a variant option applier setting the random source, e.g.
+// a seeded one for deterministic tests.
+func WithSource(src rand.Source) VariantOptionApplier {
+	return func(o *VariantOptions) {
+		o.Source = src
+	}
+}
+
+// WithVariantRouteOptions is a variant option applier for the options of
+// the registered route.
+func WithVariantRouteOptions(appliers ...RouteOptionApplier) VariantOptionApplier {
+	return func(o *VariantOptions) {
+		o.RouteOptions = append(o.RouteOptions, appliers...)
+	}
+}
+
+// HandleVariants registers a route splitting the requests across the
+// variants in proportion to their weights, e.g. to send 5% of the traffic
+// to a canary:
+//
+//	m.HandleVariants(http.MethodPost, "/checkout", []mux.Variant{
+//		{Name: "stable", Weight: 95, Handler: checkout},
+//		{Name: "canary", Weight: 5, Handler: checkoutV2},
+//	}, mux.WithStickyCookie("session"))
+//
+// The name of the selected variant is returned by GetVariant, including to
+// the middlewares once the handler has returned, and is set as the
+// AttributeVariant of the span.
+//
+// It panics when there is no variant, when a weight is negative, when the
+// weights sum to zero, when a handler is nil, or when two variants have the
+// same name.
+func (m *Mux) HandleVariants(method string, path string, variants []Variant, appliers ...VariantOptionApplier) {
+	opts := &VariantOptions{}
+	for _, apply := range appliers {
+		apply(opts)
+	}
+
+	if err := checkVariants(variants); err != nil {
+		panic(err)
+	}
+
+	src := opts.Source
+	if src == nil {
+		src = rand.NewSource(time.Now().UnixNano())
+	}
+
+	vs := &variantSelector{
+		variants:  append([]Variant(nil), variants...),
+		stickyKey: opts.StickyKey,
+		rnd:       rand.New(src),
+	}
+	for _, v := range variants {
+		vs.total += v.Weight
+	}
+
+	m.Handle(method, path, vs, opts.RouteOptions...)
+}
+
+func checkVariants(variants []Variant) error {
+	if len(variants) == 0 {
+		return fmt.Errorf("variants must not be empty")
+	}
+
+	total := 0
+	names := make(map[string]bool, len(variants))
+	for _, v := range variants {
+		if v.Weight < 0 {
+			return fmt.Errorf("variant weight must not be negative. got=(%s: %d)", v.Name, v.Weight)
+		}
+
+		if v.Handler == nil {
+			return fmt.Errorf("variant handler must not be nil. got=(%s)", v.Name)
+		}
+
+		if names[v.Name] {
+			return fmt.Errorf("variant name is registered twice. got=(%s)", v.Name)
+		}
+
+		names[v.Name] = true
+		total += v.Weight
+	}
+
+	if total == 0 {
+		return fmt.Errorf("variant weights must not sum to zero")
+	}
+	return nil
+}
+
+type variantSelector struct {
+	variants  []Variant
+	total     int
+	stickyKey func(r *http.Request) string
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func (vs *variantSelector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v := vs.selectVariant(r)
+
+	getRequestInfo(r.Context()).variant = v.Name
+	if span := GetSpan(r.Context()); span != nil {
+		span.SetAttributes(Attribute{Key: AttributeVariant, Value: v.Name})
+	}
+
+	v.Handler.ServeHTTP(w, r)
+}
+
+func (vs *variantSelector) selectVariant(r *http.Request) Variant {
+	var n int
+	if key := vs.key(r); key != "" {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		n = int(h.Sum64() % uint64(vs.total))
+	} else {
+		vs.mu.Lock()
+		n = vs.rnd.Intn(vs.total)
+		vs.mu.Unlock()
+	}
+
+	for _, v := range vs.variants {
+		if n < v.Weight {
+			return v
+		}
+		n -= v.Weight
+	}
+
+	// unreachable, since n is less than the sum of the weights.
+	return vs.variants[len(vs.variants)-1]
+}
+
+func (vs *variantSelector) key(r *http.Request) string {
+	if vs.stickyKey == nil {
+		return ""
+	}
+	return vs.stickyKey(r)
+}
+
+// GetVariant returns the name of the variant selected for the request by a
+// route registered with HandleVariants. It returns an empty string for the
+// other routes.
+func GetVariant(ctx context.Context) string {
+	return getRequestInfo(ctx).variant
+}
@@ -0,0 +1,125 @@
+package mux_test
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/tracetest"
+)
+
+func named(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(name))
+	})
+}
+
+func TestMux_HandleVariants(t *testing.T) {
+	recorder := tracetest.NewRecorder()
+	handler := mux.New(mux.WithTracer(recorder))
+
+	var logged string
+	handler.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			logged = mux.GetVariant(r.Context())
+		})
+	})
+
+	handler.HandleVariants(http.MethodPost, "/checkout", []mux.Variant{
+		{Name: "stable", Weight: 95, Handler: named("stable")},
+		{Name: "canary", Weight: 5, Handler: named("canary")},
+		{Name: "disabled", Weight: 0, Handler: named("disabled")},
+	}, mux.WithSource(rand.NewSource(42)))
+
+	// replays the selection with the same seed.
+	rnd := rand.New(rand.NewSource(42))
+	counts := make(map[string]int)
+
+	for i := 0; i < 1000; i++ {
+		exp := "stable"
+		if rnd.Intn(100) >= 95 {
+			exp = "canary"
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/checkout", nil))
+
+		if rec.Body.String() != exp || logged != exp {
+			t.Fatalf("%d: expected variant %q; got %q logged as %q", i, exp, rec.Body.String(), logged)
+		}
+		counts[exp]++
+	}
+
+	if counts["canary"] < 30 || counts["canary"] > 70 {
+		t.Fatalf("expected about 5%% of canary; got %d/1000", counts["canary"])
+	}
+
+	spans := recorder.Ended()
+	if got := spans[len(spans)-1].Attributes[mux.AttributeVariant]; got != logged {
+		t.Fatalf("expected span attribute %q; got %v", logged, got)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	if logged != "" {
+		t.Fatalf("expected no variant; got %q", logged)
+	}
+}
+
+func TestMux_HandleVariants_Sticky(t *testing.T) {
+	handler := mux.New()
+	handler.HandleVariants(http.MethodGet, "/home", []mux.Variant{
+		{Name: "a", Weight: 50, Handler: named("a")},
+		{Name: "b", Weight: 50, Handler: named("b")},
+	}, mux.WithStickyHeader("X-User"), mux.WithSource(rand.NewSource(1)))
+
+	get := func(user string) string {
+		r := httptest.NewRequest(http.MethodGet, "/home", nil)
+		r.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec.Body.String()
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		user := strconv.Itoa(i)
+		first := get(user)
+		for j := 0; j < 5; j++ {
+			if got := get(user); got != first {
+				t.Fatalf("%d: expected user %s pinned to %q; got %q", i, user, first, got)
+			}
+		}
+		seen[first] = true
+	}
+
+	if !seen["a"] || !seen["b"] {
+		t.Fatalf("expected users spread across the variants; got %v", seen)
+	}
+}
+
+func TestMux_HandleVariants_Invalid(t *testing.T) {
+	tests := [][]mux.Variant{
+		nil,
+		{{Name: "a", Weight: -1, Handler: named("a")}, {Name: "b", Weight: 2, Handler: named("b")}},
+		{{Name: "a", Weight: 0, Handler: named("a")}},
+		{{Name: "a", Weight: 1, Handler: named("a")}, {Name: "a", Weight: 1, Handler: named("a")}},
+		{{Name: "a", Weight: 1, Handler: named("a")}, {Name: "b", Weight: 1}},
+	}
+
+	for i, variants := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%d: expected panic", i)
+				}
+			}()
+
+			mux.New().HandleVariants(http.MethodGet, "/", variants)
+		}()
+	}
+}
@@ -0,0 +1,283 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josestg/mux/internal/trie"
+)
+
+// VersionOptions holds the optional fields of a Version.
+type VersionOptions struct {
+	// Deprecation is when the version was deprecated, announced by the
+	// Deprecation header of its responses. Zero when it is not deprecated.
+	Deprecation time.Time
+
+	// Sunset is when the version stops being served, announced by the
+	// Sunset header of its responses. Zero when it is not planned.
+	Sunset time.Time
+}
+
+// VersionOptionApplier is a function for applying version option.
+type VersionOptionApplier func(o *VersionOptions)
+
+// WithVersionDeprecation is a version option applier marking the version
+// deprecated since the given time.
+func WithVersionDeprecation(at time.Time) VersionOptionApplier {
+	return func(o *VersionOptions) {
+		o.Deprecation = at
+	}
+}
+
+// WithVersionSunset is a version option applier setting when the version
+// stops being served.
+func WithVersionSunset(at time.Time) VersionOptionApplier {
+	return func(o *VersionOptions) {
+		o.Sunset = at
+	}
+}
+
+// Version is a version of the API registered with Mux.Version.
+type Version struct {
+	mux   *Mux
+	name  string
+	index int
+	table *routeTable
+	opts  VersionOptions
+}
+
+// Version registers the next version of the API, e.g. Version("v2") after
+// Version("v1"), and returns it for registering its routes:
+//
+//	v1 := m.Version("v1", mux.WithVersionDeprecation(deprecatedAt))
+//	v1.HandleFunc(http.MethodGet, "/users/:id", getUserV1)
+//	v1.HandleFunc(http.MethodGet, "/orders", listOrders)
+//
+//	v2 := m.Version("v2")
+//	v2.HandleFunc(http.MethodGet, "/users/:id", getUserV2)
+//
+// A request selects the version with the /{name} prefix of its path, e.g.
+// /v2/orders, with the VersionHeader, e.g. API-Version: v2, or with the
+// VersionParam of its Accept header, e.g. application/json; version=2. The
+// other requests are served by the DefaultVersion. The routes registered on
+// the Mux take precedence over the versions.
+//
+// A version serves the routes of the nearest older version it does not
+// register itself, so only the changed routes have to be registered: above,
+// /v2/orders is served by listOrders. The responses of a deprecated version
+// carry the Deprecation and Sunset headers, even when served by a route of
+// an older version.
+//
+// It panics when the name is empty, contains a slash, or is registered
+// twice.
+func (m *Mux) Version(name string, appliers ...VersionOptionApplier) *Version {
+	if name == "" || strings.Contains(name, "/") {
+		panic(fmt.Errorf("version name must be a non-empty path segment. got=(%s)", name))
+	}
+
+	for _, v := range m.versions {
+		if v.name == name {
+			panic(fmt.Errorf("version is registered twice. got=(%s)", name))
+		}
+	}
+
+	v := &Version{mux: m, name: name, index: len(m.versions), table: newRouteTable()}
+	for _, apply := range appliers {
+		apply(&v.opts)
+	}
+
+	m.versions = append(m.versions, v)
+	return v
+}
+
+// Name returns the name of the version.
+func (v *Version) Name() string {
+	return v.name
+}
+
+// Handle registers the http.Handler for the given HTTP method and URL path,
+// without the version prefix, in the version.
+func (v *Version) Handle(method string, path string, handler http.Handler, appliers ...RouteOptionApplier) {
+	rt := newRoute(method, path, handler, appliers)
+	rt.Version = v.name
+
+	if err := v.table.insert(rt); err != nil {
+		panic(err)
+	}
+}
+
+// HandleFunc registers the http.HandlerFunc for the given HTTP method and
+// URL path in the version.
+func (v *Version) HandleFunc(method string, path string, handlerFunc http.HandlerFunc, appliers ...RouteOptionApplier) {
+	v.Handle(method, path, handlerFunc, appliers...)
+}
+
+// HandleE registers the HandlerE for the given HTTP method and URL path in
+// the version.
+func (v *Version) HandleE(method string, path string, handler HandlerE, appliers ...RouteOptionApplier) {
+	v.Handle(method, path, v.mux.handlerE(handler), appliers...)
+}
+
+// search finds the route in the version, then in the older versions.
+func (v *Version) search(ctx context.Context, method string, path string) (trie.Result, error) {
+	var (
+		vars    trie.Vars
+		methods []string
+	)
+
+	for i := v.index; i >= 0; i-- {
+		res, err := v.mux.versions[i].table.search(ctx, method, path)
+		switch err {
+		case nil:
+			return res, nil
+		case trie.ErrMethodNotFound:
+			vars = res.Vars
+			methods = appendMissing(methods, res.Methods)
+		}
+	}
+
+	if len(methods) == 0 {
+		return trie.Result{}, trie.ErrPathNotFound
+	}
+
+	sort.Strings(methods)
+	return trie.Result{Vars: vars, Methods: methods}, trie.ErrMethodNotFound
+}
+
+func appendMissing(list []string, values []string) []string {
+	for _, v := range values {
+		found := false
+		for _, l := range list {
+			if l == v {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// setHeaders sets the Deprecation and Sunset headers of the version.
+func (v *Version) setHeaders(h http.Header) {
+	setDeprecationHeaders(h, v.opts.Deprecation, v.opts.Sunset)
+}
+
+// setDeprecationHeaders sets the Deprecation header defined by RFC 9745 and
+// the Sunset header defined by RFC 8594, when their time is set.
+func setDeprecationHeaders(h http.Header, deprecation, sunset time.Time) {
+	if !deprecation.IsZero() {
+		h.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Unix(), 10))
+	}
+
+	if !sunset.IsZero() {
+		h.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+}
+
+// varyVersion adds the request headers selecting the version to the Vary
+// header.
+func (m *Mux) varyVersion(h http.Header) {
+	if m.options.VersionHeader != "" {
+		h.Add("Vary", m.options.VersionHeader)
+	}
+
+	if m.options.VersionParam != "" {
+		h.Add("Vary", "Accept")
+	}
+}
+
+// lookup finds the route of the request in the routes of the Mux, then in
+// the selected version. The returned Version is the selected one when it
+// has a route for the path, and prefixed reports whether it was selected by
+// the path.
+func (m *Mux) lookup(ctx context.Context, method string, path string, header http.Header) (res trie.Result, v *Version, prefixed bool, err error) {
+	res, err = m.table.search(ctx, method, path)
+	if err == nil || len(m.versions) == 0 {
+		return res, nil, false, err
+	}
+
+	v, rest := m.selectVersion(path, header)
+	if v == nil {
+		return res, nil, false, err
+	}
+
+	vres, verr := v.search(ctx, method, rest)
+	if verr == trie.ErrPathNotFound {
+		return res, nil, false, err
+	}
+
+	return vres, v, rest != path, verr
+}
+
+// selectVersion returns the version selected by the path prefix, the
+// version header or the version parameter of the Accept header, and the
+// path without the version prefix. The version is nil when the selected
+// one is not registered.
+func (m *Mux) selectVersion(path string, header http.Header) (*Version, string) {
+	segment, rest := strings.TrimPrefix(path, "/"), "/"
+	if i := strings.IndexByte(segment, '/'); i >= 0 {
+		segment, rest = segment[:i], segment[i:]
+	}
+
+	for _, v := range m.versions {
+		if v.name == segment {
+			return v, rest
+		}
+	}
+
+	name := m.options.DefaultVersion
+	if h := header.Get(m.options.VersionHeader); h != "" {
+		name = h
+	} else if p := acceptParam(header.Get("Accept"), m.options.VersionParam); p != "" {
+		name = p
+	} else if name == "" {
+		return m.versions[0], path
+	}
+
+	return m.findVersion(name), path
+}
+
+// findVersion returns the version with the name, ignoring the case and the
+// v prefix, so that 2 selects v2.
+func (m *Mux) findVersion(name string) *Version {
+	name = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "v")
+	for _, v := range m.versions {
+		if strings.TrimPrefix(strings.ToLower(v.name), "v") == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// acceptParam returns the first value of the parameter in the media ranges
+// of the Accept header.
+func acceptParam(accept string, param string) string {
+	if accept == "" || param == "" {
+		return ""
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		for _, p := range strings.Split(part, ";")[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, param) {
+				return strings.Trim(v, `"`)
+			}
+		}
+	}
+	return ""
+}
+
+// GetVersion returns the name of the API version selected by the request.
+// It returns an empty string when the request is not served by a Version.
+func GetVersion(ctx context.Context) string {
+	return getRequestInfo(ctx).version
+}
@@ -0,0 +1,212 @@
+package mux_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+)
+
+func TestMux_Version(t *testing.T) {
+	deprecatedAt := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunsetAt := time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	handler := mux.New()
+
+	var version string
+	handler.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			version = mux.GetVersion(r.Context())
+		})
+	})
+
+	handler.Handle(http.MethodGet, "/health", named("health"))
+
+	v1 := handler.Version("v1", mux.WithVersionDeprecation(deprecatedAt), mux.WithVersionSunset(sunsetAt))
+	v1.Handle(http.MethodGet, "/users/:id", named("user v1"))
+	v1.Handle(http.MethodGet, "/orders", named("orders v1"))
+	v1.Handle(http.MethodDelete, "/users/:id", named("delete user v1"))
+
+	v2 := handler.Version("v2")
+	v2.Handle(http.MethodGet, "/users/:id", named("user v2"))
+	v2.Handle(http.MethodPut, "/users/:id", named("update user v2"))
+	v2.Handle(http.MethodGet, "/", named("index v2"))
+
+	tests := []struct {
+		method        string
+		path          string
+		header        string
+		accept        string
+		expStatus     int
+		expBody       string
+		expVersion    string
+		expDeprecated bool
+		expVary       bool
+		expAllow      string
+	}{
+		{method: http.MethodGet, path: "/v1/users/1", expStatus: http.StatusOK, expBody: "user v1", expVersion: "v1", expDeprecated: true},
+		{method: http.MethodGet, path: "/v2/users/1", expStatus: http.StatusOK, expBody: "user v2", expVersion: "v2"},
+		{method: http.MethodGet, path: "/v2/orders", expStatus: http.StatusOK, expBody: "orders v1", expVersion: "v2"},
+		{method: http.MethodGet, path: "/v2", expStatus: http.StatusOK, expBody: "index v2", expVersion: "v2"},
+		{method: http.MethodGet, path: "/v1", expStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/v3/users/1", expStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/users/1", expStatus: http.StatusOK, expBody: "user v1", expVersion: "v1", expDeprecated: true, expVary: true},
+		{method: http.MethodGet, path: "/users/1", header: "v2", expStatus: http.StatusOK, expBody: "user v2", expVersion: "v2", expVary: true},
+		{method: http.MethodGet, path: "/users/1", header: "V2", expStatus: http.StatusOK, expBody: "user v2", expVersion: "v2", expVary: true},
+		{method: http.MethodGet, path: "/users/1", accept: "application/json; version=2", expStatus: http.StatusOK, expBody: "user v2", expVersion: "v2", expVary: true},
+		{method: http.MethodGet, path: "/orders", accept: `text/html, application/json;q=0.9;version="v2"`, expStatus: http.StatusOK, expBody: "orders v1", expVersion: "v2", expVary: true},
+		{method: http.MethodGet, path: "/users/1", header: "v9", expStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/v2/health", expStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/health", header: "v2", expStatus: http.StatusOK, expBody: "health"},
+		{method: http.MethodPost, path: "/v2/users/1", expStatus: http.StatusMethodNotAllowed, expVersion: "v2", expAllow: "DELETE, GET, PUT"},
+		{method: http.MethodPut, path: "/v1/users/1", expStatus: http.StatusMethodNotAllowed, expVersion: "v1", expDeprecated: true, expAllow: "DELETE, GET"},
+		{method: http.MethodDelete, path: "/v2/users/1", expStatus: http.StatusOK, expBody: "delete user v1", expVersion: "v2"},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.header != "" {
+			r.Header.Set("API-Version", tc.header)
+		}
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatus {
+			t.Fatalf("%d: expected status code %d; got %d", i, tc.expStatus, rec.Code)
+		}
+
+		if tc.expBody != "" && rec.Body.String() != tc.expBody {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.expBody, rec.Body.String())
+		}
+
+		if version != tc.expVersion {
+			t.Fatalf("%d: expected version %q; got %q", i, tc.expVersion, version)
+		}
+
+		if got := rec.Header().Get("Allow"); got != tc.expAllow {
+			t.Fatalf("%d: expected Allow %q; got %q", i, tc.expAllow, got)
+		}
+
+		expDeprecation, expSunset := "", ""
+		if tc.expDeprecated {
+			expDeprecation, expSunset = "@1767225600", "Thu, 31 Dec 2026 00:00:00 GMT"
+		}
+
+		if got := rec.Header().Get("Deprecation"); got != expDeprecation {
+			t.Fatalf("%d: expected Deprecation %q; got %q", i, expDeprecation, got)
+		}
+
+		if got := rec.Header().Get("Sunset"); got != expSunset {
+			t.Fatalf("%d: expected Sunset %q; got %q", i, expSunset, got)
+		}
+
+		if got := len(rec.Header().Values("Vary")) > 0; got != tc.expVary {
+			t.Fatalf("%d: expected Vary %v; got %v", i, tc.expVary, rec.Header().Values("Vary"))
+		}
+	}
+}
+
+func TestMux_Version_Default(t *testing.T) {
+	handler := mux.New(mux.WithDefaultVersion("v2"), mux.WithVersionSelection("X-Version", ""))
+	handler.Version("v1").Handle(http.MethodGet, "/users", named("users v1"))
+	handler.Version("v2").Handle(http.MethodGet, "/users", named("users v2"))
+
+	tests := []struct {
+		header  string
+		accept  string
+		expBody string
+	}{
+		{expBody: "users v2"},
+		{header: "1", expBody: "users v1"},
+		{accept: "application/json; version=1", expBody: "users v2"},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/users", nil)
+		if tc.header != "" {
+			r.Header.Set("X-Version", tc.header)
+		}
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Body.String() != tc.expBody {
+			t.Fatalf("%d: expected body %q; got %q", i, tc.expBody, rec.Body.String())
+		}
+
+		if got := rec.Header().Values("Vary"); len(got) != 1 || got[0] != "X-Version" {
+			t.Fatalf("%d: expected Vary [X-Version]; got %v", i, got)
+		}
+	}
+
+	mr, err := handler.Match(http.MethodGet, "/v1/users")
+	if err != nil || mr.Route.Version != "v1" {
+		t.Fatalf("expected route of v1; got %+v, %v", mr.Route, err)
+	}
+
+	mr, err = handler.Match(http.MethodGet, "/users")
+	if err != nil || mr.Route.Version != "v2" {
+		t.Fatalf("expected route of v2; got %+v, %v", mr.Route, err)
+	}
+
+	if _, err := handler.Match(http.MethodPost, "/v1/users"); !errors.Is(err, mux.ErrMethodNotAllowed) {
+		t.Fatalf("expected error %v; got %v", mux.ErrMethodNotAllowed, err)
+	}
+}
+
+func TestRoute_VersionedPattern(t *testing.T) {
+	handler := mux.New()
+	handler.Handle(http.MethodGet, "/health", named("health"))
+	handler.Version("v1").Handle(http.MethodGet, "/users/:id", named("user v1"))
+	handler.Version("v2").Handle(http.MethodGet, "/", named("index v2"))
+
+	tests := []struct {
+		path       string
+		expPattern string
+	}{
+		{path: "/health", expPattern: "/health"},
+		{path: "/v1/users/1", expPattern: "/v1/users/:id"},
+		{path: "/v2/users/1", expPattern: "/v1/users/:id"},
+		{path: "/v2", expPattern: "/v2"},
+	}
+
+	for i, tc := range tests {
+		mr, err := handler.Match(http.MethodGet, tc.path)
+		if err != nil {
+			t.Fatalf("%d: expected no error; got %v", i, err)
+		}
+
+		if got := mr.Route.VersionedPattern(); got != tc.expPattern {
+			t.Fatalf("%d: expected pattern %q; got %q", i, tc.expPattern, got)
+		}
+	}
+}
+
+func TestMux_Version_Invalid(t *testing.T) {
+	tests := []string{"", "v1/beta", "v1"}
+
+	for i, name := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%d: expected panic", i)
+				}
+			}()
+
+			handler := mux.New()
+			handler.Version("v1")
+			handler.Version(name)
+		}()
+	}
+}
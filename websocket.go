@@ -0,0 +1,54 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/josestg/mux/websocket"
+)
+
+type webSocketKey struct{}
+
+// WebSocketHandler serves a WebSocket connection. The connection is closed
+// when it returns: normally when it returns nil or the error of a peer
+// closing the connection, and with websocket.CloseInternalError otherwise.
+type WebSocketHandler func(ctx context.Context, conn *websocket.Conn) error
+
+// WithWebSocketOptions is a route option applier setting the websocket
+// options of a route registered with HandleWebSocket, e.g. its
+// subprotocols or its origin check.
+func WithWebSocketOptions(appliers ...websocket.OptionApplier) RouteOptionApplier {
+	return WithValue(webSocketKey{}, appliers)
+}
+
+// HandleWebSocket registers a GET route upgrading the requests to the
+// WebSocket protocol and serving them with the WebSocketHandler. The route
+// opts out of the Timeout middleware, which the route options can override.
+//
+// Requests without a valid opening handshake are answered by the
+// ErrorHandler with the status code of the websocket.HandshakeError, e.g.
+// 426 Upgrade Required when they do not ask for an upgrade.
+func (m *Mux) HandleWebSocket(path string, handler WebSocketHandler, appliers ...RouteOptionApplier) {
+	appliers = append([]RouteOptionApplier{WithTimeout(0)}, appliers...)
+
+	m.Handle(http.MethodGet, path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var options []websocket.OptionApplier
+		if rt := GetRoute(r.Context()); rt != nil {
+			options, _ = rt.Value(webSocketKey{}).([]websocket.OptionApplier)
+		}
+
+		conn, err := websocket.Upgrade(w, r, options...)
+		if err != nil {
+			m.options.ErrorHandler(w, r, err)
+			return
+		}
+
+		code := websocket.CloseNormal
+		var ce *websocket.CloseError
+		if err := handler(r.Context(), conn); err != nil && !errors.As(err, &ce) {
+			code = websocket.CloseInternalError
+		}
+		_ = conn.Close(code, "")
+	}), appliers...)
+}
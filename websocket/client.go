@@ -0,0 +1,152 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Dial performs the client side of the opening handshake with the server at
+// rawURL, a ws, wss, http or https URL, with Default option. The header is
+// sent with the handshake request, e.g. for the Origin. The Subprotocols
+// are offered to the server and the ReadLimit applies to the messages read.
+//
+// The deadline of ctx bounds the dial and the handshake. When the server
+// refuses the handshake, a *HandshakeError is returned with the response,
+// whose body is already closed.
+func Dial(ctx context.Context, rawURL string, header http.Header, appliers ...OptionApplier) (*Conn, *http.Response, error) {
+	var options Options
+	Default()(&options)
+
+	for _, apply := range appliers {
+		apply(&options)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var secure bool
+	switch u.Scheme {
+	case "ws", "http":
+		u.Scheme = "http"
+	case "wss", "https":
+		u.Scheme, secure = "https", true
+	default:
+		return nil, nil, &HandshakeError{Reason: "unsupported scheme " + u.Scheme}
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if secure {
+			port = "443"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	var d net.Dialer
+	netConn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = netConn.SetDeadline(deadline)
+	}
+
+	if secure {
+		tlsConn := tls.Client(netConn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = netConn.Close()
+			return nil, nil, err
+		}
+		netConn = tlsConn
+	}
+
+	conn, resp, err := handshake(netConn, u, header, options)
+	if err != nil {
+		_ = netConn.Close()
+		return nil, resp, err
+	}
+
+	_ = netConn.SetDeadline(time.Time{})
+	return conn, resp, nil
+}
+
+func handshake(netConn net.Conn, u *url.URL, header http.Header, options Options) (*Conn, *http.Response, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Host:       u.Host,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header.Clone(),
+	}
+
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if len(options.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(options.Subprotocols, ", "))
+	}
+
+	if err := req.Write(netConn); err != nil {
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		_ = resp.Body.Close()
+		return nil, resp, &HandshakeError{Status: resp.StatusCode, Reason: "unexpected status " + resp.Status}
+	}
+
+	if !hasToken(resp.Header, "Upgrade", "websocket") || !hasToken(resp.Header, "Connection", "upgrade") {
+		return nil, resp, &HandshakeError{Status: resp.StatusCode, Reason: "missing upgrade headers"}
+	}
+
+	if resp.Header.Get("Sec-WebSocket-Accept") != computeAccept(key) {
+		return nil, resp, &HandshakeError{Status: resp.StatusCode, Reason: "invalid Sec-WebSocket-Accept"}
+	}
+
+	protocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	if protocol != "" && !contains(options.Subprotocols, protocol) {
+		return nil, resp, &HandshakeError{Status: resp.StatusCode, Reason: "unexpected subprotocol " + protocol}
+	}
+
+	return newConn(netConn, br, true, protocol, options.ReadLimit), resp, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
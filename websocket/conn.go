@@ -0,0 +1,391 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType is the type of a data message.
+type MessageType int
+
+// The data message types.
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+// The close codes defined by RFC 6455.
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseAbnormal        = 1006
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// CloseError is returned by ReadMessage when the peer closed the
+// connection, or when the connection was closed because the peer broke the
+// protocol.
+type CloseError struct {
+	// Code is the close code, CloseNoStatus when the peer sent none.
+	Code int
+
+	// Reason is the close reason sent by the peer.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *CloseError) Error() string {
+	msg := "websocket: closed with code " + strconv.Itoa(e.Code)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+
+	finBit  = 0x80
+	rsvBits = 0x70
+	maskBit = 0x80
+
+	maxControlPayload = 125
+)
+
+// Conn is a WebSocket connection. ReadMessage must be called from a single
+// goroutine, the other methods are safe for concurrent use.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	client      bool
+	subprotocol string
+	readLimit   int64
+
+	wmu       sync.Mutex
+	closeSent bool
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, client bool, subprotocol string, readLimit int64) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+
+	return &Conn{
+		conn:        conn,
+		br:          br,
+		client:      client,
+		subprotocol: subprotocol,
+		readLimit:   readLimit,
+	}
+}
+
+// Subprotocol returns the negotiated subprotocol, empty when none was.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns the address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadDeadline sets the deadline of ReadMessage.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline of the writes.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// ReadMessage reads the next data message. Pings are answered and pongs
+// are skipped while waiting for it.
+//
+// When the peer sends a close frame, it is replied to and a *CloseError is
+// returned. A message larger than the read limit closes the connection with
+// CloseMessageTooBig and returns ErrMessageTooLarge, and a protocol
+// violation closes it with the matching code and returns a *CloseError.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	var (
+		typ     MessageType
+		message []byte
+		started bool
+	)
+
+	for {
+		f, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch f.opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, f.payload); err != nil && err != ErrClosed {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			return 0, nil, c.handleClose(f.payload)
+		case opText, opBinary:
+			if started {
+				return 0, nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			typ, started = MessageType(f.opcode), true
+		case opContinuation:
+			if !started {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if c.readLimit > 0 && int64(len(message)+len(f.payload)) > c.readLimit {
+			_ = c.fail(CloseMessageTooBig, "")
+			return 0, nil, ErrMessageTooLarge
+		}
+		message = append(message, f.payload...)
+
+		if !f.fin {
+			continue
+		}
+
+		if typ == TextMessage && !utf8.Valid(message) {
+			return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8")
+		}
+
+		if message == nil {
+			message = []byte{}
+		}
+		return typ, message, nil
+	}
+}
+
+// WriteMessage writes a data message in a single frame.
+func (c *Conn) WriteMessage(typ MessageType, data []byte) error {
+	if typ != TextMessage && typ != BinaryMessage {
+		return errors.New("websocket: invalid message type")
+	}
+	return c.writeFrame(byte(typ), data)
+}
+
+// Ping sends a ping with the given payload, at most 125 bytes.
+func (c *Conn) Ping(data []byte) error {
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: control payload too large")
+	}
+	return c.writeFrame(opPing, data)
+}
+
+// Close sends a close frame with the given code and reason, unless one
+// was already sent, then closes the connection. The peer is not waited
+// for.
+func (c *Conn) Close(code int, reason string) error {
+	err := c.writeClose(code, reason)
+	if cerr := c.conn.Close(); err == nil || err == ErrClosed {
+		err = cerr
+	}
+	return err
+}
+
+func (c *Conn) handleClose(payload []byte) error {
+	ce := &CloseError{Code: CloseNoStatus}
+
+	switch {
+	case len(payload) == 1:
+		return c.fail(CloseProtocolError, "invalid close payload")
+	case len(payload) >= 2:
+		ce.Code = int(binary.BigEndian.Uint16(payload))
+		ce.Reason = string(payload[2:])
+		if !validCloseCode(ce.Code) || !utf8.ValidString(ce.Reason) {
+			return c.fail(CloseProtocolError, "invalid close payload")
+		}
+	}
+
+	// echoes the code, as the closing handshake requires.
+	code := ce.Code
+	if code == CloseNoStatus {
+		code = CloseNormal
+	}
+	_ = c.writeClose(code, "")
+	_ = c.conn.Close()
+
+	return ce
+}
+
+// fail closes the connection after a protocol violation of the peer.
+func (c *Conn) fail(code int, reason string) error {
+	_ = c.Close(code, reason)
+	return &CloseError{Code: code, Reason: reason}
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code >= 1000 && code <= 1011:
+		return code != 1004 && code != CloseNoStatus && code != CloseAbnormal
+	default:
+		return false
+	}
+}
+
+type frame struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+func (c *Conn) readFrame() (frame, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return frame{}, err
+	}
+
+	f := frame{fin: head[0]&finBit != 0, opcode: head[0] & 0x0F}
+	masked := head[1]&maskBit != 0
+
+	if head[0]&rsvBits != 0 {
+		return frame{}, c.fail(CloseProtocolError, "reserved bits set")
+	}
+
+	// clients must mask their frames and servers must not.
+	if masked == c.client {
+		return frame{}, c.fail(CloseProtocolError, "invalid masking")
+	}
+
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return frame{}, err
+		}
+		n = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return frame{}, err
+		}
+		n = binary.BigEndian.Uint64(b[:])
+		if n > math.MaxInt64 {
+			return frame{}, c.fail(CloseProtocolError, "invalid payload length")
+		}
+	}
+
+	if f.opcode >= opClose && (n > maxControlPayload || !f.fin) {
+		return frame{}, c.fail(CloseProtocolError, "invalid control frame")
+	}
+
+	if c.readLimit > 0 && n > uint64(c.readLimit) {
+		_ = c.fail(CloseMessageTooBig, "")
+		return frame{}, ErrMessageTooLarge
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return frame{}, err
+		}
+	}
+
+	// without a read limit, the length is not trusted for the allocation.
+	payload, err := io.ReadAll(io.LimitReader(c.br, int64(n)))
+	if err != nil {
+		return frame{}, err
+	}
+	if uint64(len(payload)) != n {
+		return frame{}, io.ErrUnexpectedEOF
+	}
+	f.payload = payload
+
+	if masked {
+		mask(key, f.payload)
+	}
+	return f, nil
+}
+
+func (c *Conn) writeClose(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	}
+	return c.writeFrame(opClose, payload)
+}
+
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.closeSent {
+		return ErrClosed
+	}
+	if opcode == opClose {
+		c.closeSent = true
+	}
+
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, finBit|opcode)
+
+	var maskFlag byte
+	if c.client {
+		maskFlag = maskBit
+	}
+
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskFlag|byte(n))
+	case n <= 0xFFFF:
+		buf = append(buf, maskFlag|126, byte(n>>8), byte(n))
+	default:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(n))
+		buf = append(append(buf, maskFlag|127), b[:]...)
+	}
+
+	if c.client {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		buf = append(buf, key[:]...)
+		start := len(buf)
+		buf = append(buf, payload...)
+		mask(key, buf[start:])
+	} else {
+		buf = append(buf, payload...)
+	}
+
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+func mask(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i%4]
+	}
+}
@@ -0,0 +1,93 @@
+package websocket
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+// clientFrame encodes a masked frame with a payload shorter than 126 bytes.
+func clientFrame(fin bool, opcode byte, payload string) []byte {
+	b := []byte{opcode, maskBit | byte(len(payload))}
+	if fin {
+		b[0] |= finBit
+	}
+
+	key := [4]byte{1, 2, 3, 4}
+	p := []byte(payload)
+	mask(key, p)
+
+	return append(append(b, key[:]...), p...)
+}
+
+func TestConn_ReadMessage_Frames(t *testing.T) {
+	tests := []struct {
+		frames  [][]byte
+		expType MessageType
+		expData string
+		expCode int
+	}{
+		{
+			frames: [][]byte{
+				clientFrame(false, opText, "hel"),
+				clientFrame(true, opPing, "ping"),
+				clientFrame(false, opContinuation, "lo "),
+				clientFrame(true, opContinuation, "world"),
+			},
+			expType: TextMessage,
+			expData: "hello world",
+		},
+		{
+			frames:  [][]byte{clientFrame(true, opPong, ""), clientFrame(true, opBinary, "\xff")},
+			expType: BinaryMessage,
+			expData: "\xff",
+		},
+		{frames: [][]byte{clientFrame(true, opText, "\xff")}, expCode: CloseInvalidPayload},
+		{frames: [][]byte{clientFrame(true, opContinuation, "x")}, expCode: CloseProtocolError},
+		{frames: [][]byte{clientFrame(false, opText, "a"), clientFrame(true, opText, "b")}, expCode: CloseProtocolError},
+		{frames: [][]byte{clientFrame(false, opPing, "")}, expCode: CloseProtocolError},
+		{frames: [][]byte{clientFrame(true, 0x3, "")}, expCode: CloseProtocolError},
+		{frames: [][]byte{{finBit | rsvBits | opText, maskBit}}, expCode: CloseProtocolError},
+		{frames: [][]byte{{finBit | opText, 1, 'x'}}, expCode: CloseProtocolError},
+		{frames: [][]byte{clientFrame(true, opClose, "\x03\xe8done")}, expCode: CloseNormal},
+		{frames: [][]byte{clientFrame(true, opClose, "\x03\xed")}, expCode: CloseProtocolError},
+	}
+
+	for i, tc := range tests {
+		server, client := net.Pipe()
+		c := newConn(server, nil, false, "", 0)
+
+		// drains the frames written by the server, e.g. pongs and closes.
+		go func() {
+			buf := make([]byte, 512)
+			for {
+				if _, err := client.Read(buf); err != nil {
+					return
+				}
+			}
+		}()
+
+		go func() {
+			for _, f := range tc.frames {
+				if _, err := client.Write(f); err != nil {
+					return
+				}
+			}
+		}()
+
+		typ, data, err := c.ReadMessage()
+		_ = client.Close()
+
+		if tc.expCode != 0 {
+			var ce *CloseError
+			if !errors.As(err, &ce) || ce.Code != tc.expCode {
+				t.Fatalf("%d: expected close error %d; got %v", i, tc.expCode, err)
+			}
+			continue
+		}
+
+		if err != nil || typ != tc.expType || string(data) != tc.expData {
+			t.Fatalf("%d: expected %d %q; got %d %q, %v", i, tc.expType, tc.expData, typ, data, err)
+		}
+	}
+}
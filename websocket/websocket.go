@@ -0,0 +1,246 @@
+// Package websocket implements the server handshake, the client handshake
+// and the framing of the WebSocket protocol.
+//
+// It covers what RFC 6455 requires of endpoints without extensions:
+// fragmented messages are reassembled, pings are answered, UTF-8 text is
+// validated and the closing handshake is replied to. Compression and other
+// extensions are not negotiated.
+//
+//	func echo(ctx context.Context, c *websocket.Conn) error {
+//		for {
+//			typ, msg, err := c.ReadMessage()
+//			if err != nil {
+//				return err
+//			}
+//			if err := c.WriteMessage(typ, msg); err != nil {
+//				return err
+//			}
+//		}
+//	}
+//
+// See: https://www.rfc-editor.org/rfc/rfc6455.
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrHijackUnsupported is returned by Upgrade when the connection of
+	// the ResponseWriter can not be taken over.
+	ErrHijackUnsupported = errors.New("websocket: hijacking unsupported")
+
+	// ErrMessageTooLarge is returned by ReadMessage when a message exceeds
+	// the read limit.
+	ErrMessageTooLarge = errors.New("websocket: message too large")
+
+	// ErrClosed is returned when writing after the close frame was sent.
+	ErrClosed = errors.New("websocket: connection closed")
+)
+
+// HandshakeError is returned by Upgrade when the request is not a valid
+// opening handshake, and by Dial when the server refused it.
+type HandshakeError struct {
+	// Status is the HTTP status code of the refusal.
+	Status int
+
+	// Reason describes what is wrong with the handshake.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *HandshakeError) Error() string {
+	return "websocket: bad handshake: " + e.Reason
+}
+
+// StatusCode returns the HTTP status code of the refusal.
+func (e *HandshakeError) StatusCode() int {
+	return e.Status
+}
+
+// OptionApplier is a function for applying option.
+type OptionApplier func(o *Options)
+
+// Options holds the Upgrade optional fields.
+type Options struct {
+	// ReadLimit is the maximum size of a message. Larger messages close the
+	// connection with CloseMessageTooBig. Zero disables the limit.
+	ReadLimit int64
+
+	// Subprotocols are the subprotocols supported by the server, in order
+	// of preference.
+	Subprotocols []string
+
+	// CheckOrigin reports whether the Origin of the request is allowed.
+	// The default only allows requests without Origin or from the same
+	// host, since browsers do not apply the same-origin policy to
+	// WebSockets.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// Default is a default option applier.
+func Default() OptionApplier {
+	return func(o *Options) {
+		o.ReadLimit = 1 << 20
+		o.Subprotocols = nil
+		o.CheckOrigin = sameOrigin
+	}
+}
+
+// WithReadLimit sets the ReadLimit.
+func WithReadLimit(n int64) OptionApplier {
+	return func(o *Options) {
+		o.ReadLimit = n
+	}
+}
+
+// WithSubprotocols sets the Subprotocols.
+func WithSubprotocols(protocols ...string) OptionApplier {
+	return func(o *Options) {
+		o.Subprotocols = protocols
+	}
+}
+
+// WithCheckOrigin sets the CheckOrigin.
+func WithCheckOrigin(fn func(r *http.Request) bool) OptionApplier {
+	return func(o *Options) {
+		o.CheckOrigin = fn
+	}
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// acceptKey is the GUID appended to the key of the handshake.
+const acceptKey = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+func computeAccept(key string) string {
+	h := sha1.Sum([]byte(key + acceptKey))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// IsUpgrade reports whether r asks for a WebSocket upgrade.
+func IsUpgrade(r *http.Request) bool {
+	return hasToken(r.Header, "Connection", "upgrade") && hasToken(r.Header, "Upgrade", "websocket")
+}
+
+// hasToken reports whether the comma separated header values contain token.
+func hasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Upgrade performs the server side of the opening handshake with Default
+// option and takes over the connection. On success nothing must be written
+// to w anymore.
+//
+// An invalid handshake returns a *HandshakeError, whose status code is 426
+// Upgrade Required when r does not ask for an upgrade, or asks for another
+// version of the protocol. The response headers telling the client what to
+// ask for are set on w, but the response itself is left to the caller.
+func Upgrade(w http.ResponseWriter, r *http.Request, appliers ...OptionApplier) (*Conn, error) {
+	var options Options
+	Default()(&options)
+
+	for _, apply := range appliers {
+		apply(&options)
+	}
+
+	if r.Method != http.MethodGet {
+		return nil, &HandshakeError{Status: http.StatusMethodNotAllowed, Reason: "method is not GET"}
+	}
+
+	if !IsUpgrade(r) {
+		w.Header().Set("Connection", "Upgrade")
+		w.Header().Set("Upgrade", "websocket")
+		return nil, &HandshakeError{Status: http.StatusUpgradeRequired, Reason: "not an upgrade request"}
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, &HandshakeError{Status: http.StatusUpgradeRequired, Reason: "unsupported version"}
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return nil, &HandshakeError{Status: http.StatusBadRequest, Reason: "invalid Sec-WebSocket-Key"}
+	}
+
+	if options.CheckOrigin != nil && !options.CheckOrigin(r) {
+		return nil, &HandshakeError{Status: http.StatusForbidden, Reason: "origin not allowed"}
+	}
+
+	hj := findHijacker(w)
+	if hj == nil {
+		return nil, ErrHijackUnsupported
+	}
+
+	protocol := selectSubprotocol(r, options.Subprotocols)
+
+	netConn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	// the server may have set deadlines for the HTTP exchange.
+	_ = netConn.SetDeadline(time.Time{})
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + computeAccept(key) + "\r\n")
+	if protocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + protocol + "\r\n")
+	}
+	b.WriteString("\r\n")
+
+	if _, err := netConn.Write([]byte(b.String())); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+
+	return newConn(netConn, brw.Reader, false, protocol, options.ReadLimit), nil
+}
+
+// findHijacker returns the http.Hijacker of w, looking through the writers
+// wrapping it.
+func findHijacker(w http.ResponseWriter) http.Hijacker {
+	for {
+		if hj, ok := w.(http.Hijacker); ok {
+			return hj
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+}
+
+func selectSubprotocol(r *http.Request, supported []string) string {
+	for _, s := range supported {
+		if hasToken(r.Header, "Sec-WebSocket-Protocol", s) {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package websocket_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux/websocket"
+)
+
+func TestUpgrade_Handshake(t *testing.T) {
+	valid := map[string]string{
+		"Connection":            "keep-alive, Upgrade",
+		"Upgrade":               "websocket",
+		"Sec-WebSocket-Version": "13",
+		"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+	}
+
+	with := func(k, v string) map[string]string {
+		h := make(map[string]string)
+		for hk, hv := range valid {
+			h[hk] = hv
+		}
+		h[k] = v
+		return h
+	}
+
+	tests := []struct {
+		method    string
+		header    map[string]string
+		expStatus int
+		expHeader string
+	}{
+		{method: http.MethodPost, header: valid, expStatus: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, header: map[string]string{}, expStatus: http.StatusUpgradeRequired, expHeader: "Upgrade"},
+		{method: http.MethodGet, header: with("Upgrade", "h2c"), expStatus: http.StatusUpgradeRequired, expHeader: "Upgrade"},
+		{method: http.MethodGet, header: with("Sec-WebSocket-Version", "8"), expStatus: http.StatusUpgradeRequired, expHeader: "Sec-WebSocket-Version"},
+		{method: http.MethodGet, header: with("Sec-WebSocket-Key", "short"), expStatus: http.StatusBadRequest},
+		{method: http.MethodGet, header: with("Origin", "https://evil.example"), expStatus: http.StatusForbidden},
+		{method: http.MethodGet, header: with("Origin", "http://example.com")},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(tc.method, "http://example.com/ws", nil)
+		for k, v := range tc.header {
+			r.Header.Set(k, v)
+		}
+
+		rec := httptest.NewRecorder()
+		_, err := websocket.Upgrade(rec, r)
+
+		if tc.expStatus == 0 {
+			// the handshake is valid, but the recorder can not be hijacked.
+			if err != websocket.ErrHijackUnsupported {
+				t.Fatalf("%d: expected error %v; got %v", i, websocket.ErrHijackUnsupported, err)
+			}
+			continue
+		}
+
+		var he *websocket.HandshakeError
+		if !errors.As(err, &he) || he.StatusCode() != tc.expStatus {
+			t.Fatalf("%d: expected handshake error with status %d; got %v", i, tc.expStatus, err)
+		}
+
+		if tc.expHeader != "" && rec.Header().Get(tc.expHeader) == "" {
+			t.Fatalf("%d: expected header %s", i, tc.expHeader)
+		}
+	}
+}
+
+func TestConn_Echo(t *testing.T) {
+	closed := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Upgrade(w, r, websocket.WithSubprotocols("v2", "v1"))
+		if err != nil {
+			t.Errorf("expected no error; got %v", err)
+			return
+		}
+
+		for {
+			typ, msg, err := c.ReadMessage()
+			if err != nil {
+				closed <- err
+				return
+			}
+			if err := c.WriteMessage(typ, msg); err != nil {
+				closed <- err
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	c, resp, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil, websocket.WithSubprotocols("v1", "v2"))
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols || c.Subprotocol() != "v2" {
+		t.Fatalf("expected subprotocol v2; got %d %q", resp.StatusCode, c.Subprotocol())
+	}
+
+	if err := c.Ping([]byte("are you there?")); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	messages := []struct {
+		typ  websocket.MessageType
+		data []byte
+	}{
+		{typ: websocket.TextMessage, data: []byte("hello")},
+		{typ: websocket.BinaryMessage, data: []byte{0, 1, 2}},
+		{typ: websocket.TextMessage, data: []byte{}},
+		{typ: websocket.BinaryMessage, data: bytes.Repeat([]byte("a"), 300)},
+		{typ: websocket.BinaryMessage, data: bytes.Repeat([]byte("b"), 70000)},
+	}
+
+	for i, m := range messages {
+		if err := c.WriteMessage(m.typ, m.data); err != nil {
+			t.Fatalf("%d: expected no error; got %v", i, err)
+		}
+
+		typ, data, err := c.ReadMessage()
+		if err != nil || typ != m.typ || !bytes.Equal(data, m.data) {
+			t.Fatalf("%d: expected echo of %d bytes; got %d bytes, %v", i, len(m.data), len(data), err)
+		}
+	}
+
+	if err := c.Close(websocket.CloseGoingAway, "bye"); err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	var ce *websocket.CloseError
+	if err := <-closed; !errors.As(err, &ce) || ce.Code != websocket.CloseGoingAway || ce.Reason != "bye" {
+		t.Fatalf("expected close error %d; got %v", websocket.CloseGoingAway, err)
+	}
+}
+
+func TestConn_ReadLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Upgrade(w, r, websocket.WithReadLimit(10))
+		if err != nil {
+			return
+		}
+
+		if _, _, err := c.ReadMessage(); err != websocket.ErrMessageTooLarge {
+			t.Errorf("expected error %v; got %v", websocket.ErrMessageTooLarge, err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, _, err := websocket.Dial(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	_ = c.WriteMessage(websocket.TextMessage, []byte("more than ten bytes"))
+
+	var ce *websocket.CloseError
+	if _, _, err := c.ReadMessage(); !errors.As(err, &ce) || ce.Code != websocket.CloseMessageTooBig {
+		t.Fatalf("expected close error %d; got %v", websocket.CloseMessageTooBig, err)
+	}
+}
+
+func TestDial_Refused(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	_, resp, err := websocket.Dial(context.Background(), server.URL, nil)
+
+	var he *websocket.HandshakeError
+	if !errors.As(err, &he) || resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected handshake error with status %d; got %v", http.StatusNotFound, err)
+	}
+}
@@ -0,0 +1,143 @@
+package mux_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josestg/mux"
+	"github.com/josestg/mux/cache"
+	"github.com/josestg/mux/compress"
+	"github.com/josestg/mux/etag"
+	"github.com/josestg/mux/tracetest"
+	"github.com/josestg/mux/websocket"
+)
+
+func TestMux_HandleWebSocket(t *testing.T) {
+	recorder := tracetest.NewRecorder()
+	handler := mux.New(mux.WithTracer(recorder))
+	handler.Use(compress.New(), mux.Timeout(20*time.Millisecond), mux.RequestBody(1<<10))
+
+	handler.HandleWebSocket("/rooms/:room", func(ctx context.Context, conn *websocket.Conn) error {
+		room := mux.GetVars(ctx).Get("room")
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+
+			// outlive the Timeout middleware between the messages.
+			time.Sleep(10 * time.Millisecond)
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(room+": "+string(msg))); err != nil {
+				return err
+			}
+		}
+	}, mux.WithWebSocketOptions(websocket.WithSubprotocols("chat")))
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, server.URL+"/rooms/go", nil, websocket.WithSubprotocols("chat"))
+	if err != nil {
+		t.Fatalf("expected no error; got %v", err)
+	}
+
+	if conn.Subprotocol() != "chat" {
+		t.Fatalf("expected subprotocol %q; got %q", "chat", conn.Subprotocol())
+	}
+
+	for _, msg := range []string{"hello", "bye"} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("expected no error; got %v", err)
+		}
+
+		_, got, err := conn.ReadMessage()
+		if exp := "go: " + msg; err != nil || string(got) != exp {
+			t.Fatalf("expected %q; got %q, %v", exp, got, err)
+		}
+	}
+
+	_ = conn.Close(websocket.CloseNormal, "")
+}
+
+func TestMux_HandleWebSocket_Middlewares(t *testing.T) {
+	tests := []mux.MiddlewareFunc{
+		compress.New(),
+		mux.Timeout(time.Second),
+		etag.New(),
+		cache.New().Middleware,
+	}
+
+	for i, mw := range tests {
+		handler := mux.New()
+		handler.Use(mw)
+		handler.HandleWebSocket("/echo", func(ctx context.Context, conn *websocket.Conn) error {
+			typ, msg, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+			return conn.WriteMessage(typ, msg)
+		})
+
+		server := httptest.NewServer(handler)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		conn, _, err := websocket.Dial(ctx, server.URL+"/echo", nil)
+		if err != nil {
+			cancel()
+			server.Close()
+			t.Fatalf("%d: expected no error; got %v", i, err)
+		}
+
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("hello"))
+		_, got, err := conn.ReadMessage()
+		_ = conn.Close(websocket.CloseNormal, "")
+		cancel()
+		server.Close()
+
+		if err != nil || string(got) != "hello" {
+			t.Fatalf("%d: expected %q; got %q, %v", i, "hello", got, err)
+		}
+	}
+}
+
+func TestMux_HandleWebSocket_Errors(t *testing.T) {
+	handler := mux.New()
+	handler.HandleWebSocket("/ws", func(ctx context.Context, conn *websocket.Conn) error {
+		return errors.New("unreachable")
+	})
+
+	tests := []struct {
+		method    string
+		header    map[string]string
+		expStatus int
+	}{
+		{method: http.MethodGet, expStatus: http.StatusUpgradeRequired},
+		{method: http.MethodPost, expStatus: http.StatusMethodNotAllowed},
+		{
+			method:    http.MethodGet,
+			header:    map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13"},
+			expStatus: http.StatusBadRequest,
+		},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(tc.method, "/ws", nil)
+		for k, v := range tc.header {
+			r.Header.Set(k, v)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Code != tc.expStatus {
+			t.Fatalf("%d: expected status code %d; got %d", i, tc.expStatus, rec.Code)
+		}
+	}
+}